@@ -0,0 +1,84 @@
+package nchi
+
+import (
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// anyMethod is the method of routes created with Any
+const anyMethod = "*"
+
+// Any establishes a route for all HTTP methods, including custom methods
+// like PROPFIND.  Routes registered for a specific method at the same path
+// take precedence.  The standard methods (GET, HEAD, POST, PUT, PATCH,
+// DELETE, CONNECT, OPTIONS, and TRACE) and custom methods that are used
+// by other routes are reported in the Allow header of 405 (Method Not
+// Allowed) responses for other routes at the same path.
+//
+// Any routes are reported by Walk with a Method of "*".
+func (mux *Mux) Any(path string, providers ...interface{}) {
+	mux.Method(anyMethod, path, providers...)
+}
+
+func (t *table) addAny(l *leaf, path string, handle httprouter.Handle) {
+	t.any = append(t.any, registration{
+		method: anyMethod,
+		path:   path,
+		leaf:   l,
+		handle: handle,
+	})
+}
+
+// registerAny registers the routes from Any once all other
+// routes are known.
+func (t *table) registerAny() BindErrors {
+	if len(t.any) == 0 {
+		return nil
+	}
+	methods := append([]string{}, mountMethods...)
+	known := make(map[string]bool)
+	for _, method := range methods {
+		known[method] = true
+	}
+	explicit := make(map[string]bool)
+	for _, reg := range t.registered {
+		if !known[reg.method] {
+			known[reg.method] = true
+			methods = append(methods, reg.method)
+		}
+		explicit[reg.method+" "+reg.path] = true
+	}
+	var errs BindErrors
+Any:
+	for _, reg := range t.any {
+		for _, method := range methods {
+			if explicit[method+" "+reg.path] {
+				continue
+			}
+			err := t.handle(reg.leaf, method, reg.path, reg.handle)
+			if err != nil {
+				errs = append(errs, reg.leaf.bindError(err))
+				continue Any
+			}
+		}
+		err := t.addFallback(reg.path, reg.handle)
+		if err != nil {
+			errs = append(errs, reg.leaf.bindError(err))
+		}
+	}
+	return errs
+}
+
+// validMethod reports if a method is a valid HTTP token
+func validMethod(method string) bool {
+	if method == "" {
+		return false
+	}
+	for _, c := range method {
+		if c >= 0x7f || c <= ' ' || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, c) {
+			return false
+		}
+	}
+	return true
+}
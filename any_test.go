@@ -0,0 +1,56 @@
+package nchi_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/muir/nchi"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAny(t *testing.T) {
+	mux := nchi.NewRouter()
+	mux.Use("")
+	mux.Any("/a", makeDown("any"), bottom)
+	mux.Get("/a", makeDown("get"), bottom)
+	mux.Method("PROPFIND", "/dav", makeDown("propfind"), bottom)
+	mux.Method("MKCOL", "/dav", makeDown("mkcol"), bottom)
+
+	doTest(t, mux, []testCase{
+		{path: "/a", want: "get"},
+	})
+	doTestMethod(t, mux, "DELETE", []testCase{
+		{path: "/a", want: "any"},
+	})
+	doTestMethod(t, mux, "PROPFIND", []testCase{
+		{path: "/a", want: "any"},
+		{path: "/dav", want: "propfind"},
+	})
+	doTestMethod(t, mux, "REPORT", []testCase{
+		{path: "/a", want: "any"},
+		{path: "/dav", want: "Method Not Allowed\n"},
+		{path: "/b", want: "404 page not found\n"},
+	})
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/dav", nil))
+	assert.Equal(t, 405, w.Code)
+	assert.Equal(t, "MKCOL, OPTIONS, PROPFIND", w.Header().Get("Allow"))
+
+	routes := mux.Routes()
+	if assert.NotEmpty(t, routes) {
+		assert.Equal(t, "*", routes[0].Method)
+	}
+}
+
+func TestAnyErrors(t *testing.T) {
+	mux := nchi.NewRouter()
+	mux.Method("BAD METHOD", "/a", func() {})
+	assert.Regexp(t, `^bind BAD METHOD /a \(registered at .*any_test.go:\d+\): invalid method "BAD METHOD"$`, mux.Bind().Error())
+
+	mux = nchi.NewRouter()
+	mux.Any("/a", func() {})
+	mux.Any("/a", func() {})
+	assert.Regexp(t, `^bind \* /a \(registered at .*any_test.go:\d+\): conflicts with `, mux.Bind().Error())
+}
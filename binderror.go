@@ -0,0 +1,71 @@
+package nchi
+
+import (
+	"strings"
+)
+
+// BindError describes a route that could not be bound.  Bind returns
+// BindErrors which can be examined with errors.As:
+//
+//	var bindError *nchi.BindError
+//	if errors.As(err, &bindError) { ... }
+type BindError struct {
+	// Method is the HTTP method.  It is empty for special handlers that are
+	// not specific to a method.
+	Method string
+	// Path is the combined path, the same value that is injected as Endpoint.
+	Path string
+	// Special is empty for regular endpoints.  For special handlers it
+	// is the same as RouteInfo.Special.  It is "Mount" for a Mount that
+	// would mount a Mux inside itself.
+	Special string
+	// Cause is why the route could not be bound.
+	Cause error
+	// RegisteredAt is the file:line of the code that registered the route.
+	RegisteredAt string
+}
+
+func (e *BindError) Error() string {
+	what := e.Method
+	switch {
+	case e.Special != "" && e.Method != "":
+		what = e.Special + " " + e.Method
+	case e.Special != "":
+		what = e.Special
+	}
+	return "bind " + what + " " + e.Path + " (registered at " + e.RegisteredAt + "): " + e.Cause.Error()
+}
+
+func (e *BindError) Unwrap() error { return e.Cause }
+
+// BindErrors is returned by Bind when one or more routes could not be
+// bound.  It has an entry for every route that could not be bound.
+type BindErrors []*BindError
+
+func (errs BindErrors) Error() string {
+	s := make([]string, len(errs))
+	for i, err := range errs {
+		s[i] = err.Error()
+	}
+	return strings.Join(s, "\n")
+}
+
+// Unwrap supports errors.Is and errors.As
+func (errs BindErrors) Unwrap() []error {
+	unwrapped := make([]error, len(errs))
+	for i, err := range errs {
+		unwrapped[i] = err
+	}
+	return unwrapped
+}
+
+func (l *leaf) bindError(err error) *BindError {
+	method, special := l.describe()
+	return &BindError{
+		Method:       method,
+		Path:         l.combinedPath,
+		Special:      special,
+		Cause:        err,
+		RegisteredAt: l.mux.registeredAt,
+	}
+}
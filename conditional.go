@@ -0,0 +1,146 @@
+package nchi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/muir/nject/v2"
+)
+
+// Preconditions evaluates the conditional request headers (If-Match,
+// If-None-Match, If-Modified-Since, and If-Unmodified-Since) of a
+// request against the current version of a resource.  Add the
+// ConditionalRequests provider to a route to inject *Preconditions.
+//
+// ServeFiles, ServeFS, and ServeSPA compute strong ETags from the content
+// of the files they serve and handle conditional requests without any help.
+type Preconditions struct {
+	w http.ResponseWriter
+	r *http.Request
+}
+
+// ConditionalRequests is a pre-defined nject.Provider that provides
+// *Preconditions.
+var ConditionalRequests = nject.Provide("ConditionalRequests",
+	func(w http.ResponseWriter, r *http.Request) *Preconditions {
+		return &Preconditions{w: w, r: r}
+	})
+
+// StrongETag returns a strong entity tag, suitable for the ETag header,
+// for a version of a resource.  The version must not contain double quotes.
+func StrongETag(version string) string {
+	return `"` + version + `"`
+}
+
+// contentETag returns a strong entity tag from a hash of content
+func contentETag(content io.Reader) (string, error) {
+	h := sha256.New()
+	_, err := io.Copy(h, content)
+	if err != nil {
+		return "", err
+	}
+	return StrongETag(hex.EncodeToString(h.Sum(nil)[:16])), nil
+}
+
+// Check sets the ETag and Last-Modified response headers and then
+// evaluates the request preconditions, following RFC 9110.  Pass an
+// empty etag or a zero lastModified if the resource does not have one.
+// An empty etag and a zero lastModified means that the resource does
+// not exist.
+//
+// If a precondition is not met, Check writes the response, either
+// 304 (Not Modified) for GET and HEAD requests or 412 (Precondition
+// Failed), and returns true.  The handler should return without
+// writing anything else.  Otherwise Check returns false and the request
+// should be handled normally.
+//
+// For Put, Patch, and Delete routes, call Check with the current version
+// of the resource before modifying it: if the client sent an If-Match
+// header with a different version, the request is rejected with 412.
+func (p *Preconditions) Check(etag string, lastModified time.Time) bool {
+	h := p.w.Header()
+	if etag != "" {
+		h.Set("ETag", etag)
+	}
+	if !lastModified.IsZero() {
+		h.Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+	exists := etag != "" || !lastModified.IsZero()
+	safe := p.r.Method == http.MethodGet || p.r.Method == http.MethodHead
+
+	if ifMatch := p.r.Header.Get("If-Match"); ifMatch != "" {
+		if !matchETag(ifMatch, etag, exists, false) {
+			p.preconditionFailed()
+			return true
+		}
+	} else if since, ok := parseHTTPTime(p.r.Header.Get("If-Unmodified-Since")); ok && !lastModified.IsZero() {
+		if lastModified.Truncate(time.Second).After(since) {
+			p.preconditionFailed()
+			return true
+		}
+	}
+
+	if ifNoneMatch := p.r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		if matchETag(ifNoneMatch, etag, exists, true) {
+			if safe {
+				p.notModified()
+			} else {
+				p.preconditionFailed()
+			}
+			return true
+		}
+	} else if since, ok := parseHTTPTime(p.r.Header.Get("If-Modified-Since")); ok && safe && !lastModified.IsZero() {
+		if !lastModified.Truncate(time.Second).After(since) {
+			p.notModified()
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Preconditions) notModified() {
+	h := p.w.Header()
+	h.Del("Content-Type")
+	h.Del("Content-Length")
+	p.w.WriteHeader(http.StatusNotModified)
+}
+
+func (p *Preconditions) preconditionFailed() {
+	http.Error(p.w, http.StatusText(http.StatusPreconditionFailed), http.StatusPreconditionFailed)
+}
+
+// matchETag checks an etag against a list of entity tags as found in
+// If-Match and If-None-Match headers.  If-None-Match uses weak comparison.
+func matchETag(header string, etag string, exists bool, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		switch {
+		case candidate == "*":
+			if exists {
+				return true
+			}
+		case etag == "":
+		case weak:
+			if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		default:
+			if candidate == etag && !strings.HasPrefix(etag, "W/") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func parseHTTPTime(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	t, err := http.ParseTime(value)
+	return t, err == nil
+}
@@ -0,0 +1,85 @@
+package nchi_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/muir/nchi"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConditionalRequests(t *testing.T) {
+	modified := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	mux := nchi.NewRouter()
+	mux.Use(nchi.ConditionalRequests)
+	doc := func(w http.ResponseWriter, p *nchi.Preconditions) {
+		if p.Check(nchi.StrongETag("v2"), modified) {
+			return
+		}
+		_, _ = w.Write([]byte("doc"))
+	}
+	mux.Get("/doc", doc)
+	mux.Put("/doc", doc)
+	mux.Delete("/doc", doc)
+	mux.Get("/missing", func(w http.ResponseWriter, p *nchi.Preconditions) {
+		if p.Check("", time.Time{}) {
+			return
+		}
+		_, _ = w.Write([]byte("none"))
+	})
+	mux.ServeFS("/static/*filepath", fstest.MapFS{
+		"a.txt": {Data: []byte("static"), ModTime: modified},
+	})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/static/a.txt", nil))
+	staticETag := rec.Header().Get("ETag")
+	assert.Regexp(t, `^"[0-9a-f]{32}"$`, staticETag)
+
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		header string
+		value  string
+		want   int
+	}{
+		{name: "plain", method: "GET", path: "/doc", want: 200},
+		{name: "none match", method: "GET", path: "/doc", header: "If-None-Match", value: `"v1", "v2"`, want: 304},
+		{name: "none match weak", method: "GET", path: "/doc", header: "If-None-Match", value: `W/"v2"`, want: 304},
+		{name: "none match star", method: "GET", path: "/doc", header: "If-None-Match", value: `*`, want: 304},
+		{name: "none match star missing", method: "GET", path: "/missing", header: "If-None-Match", value: `*`, want: 200},
+		{name: "none match other", method: "GET", path: "/doc", header: "If-None-Match", value: `"v1"`, want: 200},
+		{name: "none match put", method: "PUT", path: "/doc", header: "If-None-Match", value: `*`, want: 412},
+		{name: "modified since", method: "GET", path: "/doc", header: "If-Modified-Since", value: modified.Format(http.TimeFormat), want: 304},
+		{name: "modified since earlier", method: "GET", path: "/doc", header: "If-Modified-Since", value: modified.Add(-time.Hour).Format(http.TimeFormat), want: 200},
+		{name: "match put", method: "PUT", path: "/doc", header: "If-Match", value: `"v2"`, want: 200},
+		{name: "match put stale", method: "PUT", path: "/doc", header: "If-Match", value: `"v1"`, want: 412},
+		{name: "match put weak", method: "PUT", path: "/doc", header: "If-Match", value: `W/"v2"`, want: 412},
+		{name: "match delete stale", method: "DELETE", path: "/doc", header: "If-Match", value: `"v1"`, want: 412},
+		{name: "unmodified since", method: "DELETE", path: "/doc", header: "If-Unmodified-Since", value: modified.Add(-time.Hour).Format(http.TimeFormat), want: 412},
+		{name: "static", method: "GET", path: "/static/a.txt", want: 200},
+		{name: "static none match", method: "GET", path: "/static/a.txt", header: "If-None-Match", value: staticETag, want: 304},
+		{name: "static modified since", method: "GET", path: "/static/a.txt", header: "If-Modified-Since", value: modified.Format(http.TimeFormat), want: 304},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tc.method, tc.path, nil)
+			if tc.header != "" {
+				r.Header.Set(tc.header, tc.value)
+			}
+			mux.ServeHTTP(w, r)
+			assert.Equal(t, tc.want, w.Code)
+			if tc.path == "/doc" {
+				assert.Equal(t, `"v2"`, w.Header().Get("ETag"))
+				assert.Equal(t, modified.Format(http.TimeFormat), w.Header().Get("Last-Modified"))
+			}
+		})
+	}
+}
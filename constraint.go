@@ -0,0 +1,144 @@
+package nchi
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+)
+
+// builtinConstraints are the constraints that can be used in
+// route patterns without registering them with WithConstraint.
+var builtinConstraints = map[string]func(string) bool{
+	"int":  regexp.MustCompile(`^-?[0-9]+$`).MatchString,
+	"uint": regexp.MustCompile(`^[0-9]+$`).MatchString,
+	"uuid": regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`).MatchString,
+}
+
+// WithConstraint registers a named constraint that can be used to
+// restrict the values of path variables.  The built-in constraints
+// are "int", "uint", and "uuid".  Constraints are used by adding
+// the constraint name in braces after the path variable:
+//
+//	mux := nchi.NewRouter(nchi.WithConstraint("even", isEven))
+//	mux.Get("/thing/:thingID{even}", handler)
+//
+// If the text in braces is not the name of a constraint, it is treated
+// as a regular expression that must match the entire value:
+//
+//	mux.Get("/article/:slug{[a-z-]+}", handler)
+//
+// Requests with path variables that do not match their constraint
+// are handled by the NotFound handler and the injection chain for the
+// endpoint is not run.  Constraints are removed from the path before it
+// is given to httprouter but they remain part of the Endpoint.
+// Constraints can only be used with :params: a constraint on a *catchall
+// causes Bind to fail.
+//
+// Like other Options, WithConstraint can be given to Route or Group in which
+// case the constraint can only be used by the routes beneath.
+func WithConstraint(name string, match func(string) bool) Option {
+	return func(r *rtr) {
+		r.constraints[name] = match
+	}
+}
+
+type paramConstraint struct {
+	name  string
+	spec  string
+	match func(string) bool
+}
+
+// constraintsFor returns the constraints registered with WithConstraint
+// combined with the built-in constraints
+func constraintsFor(options []Option) map[string]func(string) bool {
+	constraints := make(map[string]func(string) bool)
+	for name, match := range builtinConstraints {
+		constraints[name] = match
+	}
+	for name, match := range applyOptions(options).constraints {
+		constraints[name] = match
+	}
+	return constraints
+}
+
+// splitConstraints removes {constraint} from the :params in a
+// pattern and resolves the constraints.
+func splitConstraints(pattern string, constraints map[string]func(string) bool) (string, []paramConstraint, error) {
+	if !strings.Contains(pattern, "{") {
+		return pattern, nil, nil
+	}
+	var b strings.Builder
+	var pcs []paramConstraint
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		_ = b.WriteByte(c)
+		if c == '*' {
+			if brace := strings.IndexByte(pattern[i:], '{'); brace != -1 {
+				return "", nil, errors.Errorf("constraints are not supported for *%s in %s", pattern[i+1:i+brace], pattern)
+			}
+		}
+		if c != ':' {
+			continue
+		}
+		start := i + 1
+		for i+1 < len(pattern) && pattern[i+1] != '/' && pattern[i+1] != '{' {
+			i++
+			_ = b.WriteByte(pattern[i])
+		}
+		if i+1 == len(pattern) || pattern[i+1] != '{' {
+			continue
+		}
+		name := pattern[start : i+1]
+		depth := 0
+		specStart := i + 2
+		for i++; i < len(pattern); i++ {
+			switch pattern[i] {
+			case '{':
+				depth++
+			case '}':
+				depth--
+			}
+			if depth == 0 {
+				break
+			}
+		}
+		if i == len(pattern) {
+			return "", nil, errors.Errorf("unterminated constraint for :%s in %s", name, pattern)
+		}
+		pc := paramConstraint{
+			name: name,
+			spec: pattern[specStart:i],
+		}
+		if match, ok := constraints[pc.spec]; ok {
+			pc.match = match
+		} else {
+			re, err := regexp.Compile(`^(?:` + pc.spec + `)$`)
+			if err != nil {
+				return "", nil, errors.Wrapf(err, "constraint for :%s in %s", name, pattern)
+			}
+			pc.match = re.MatchString
+		}
+		pcs = append(pcs, pc)
+	}
+	return b.String(), pcs, nil
+}
+
+// constrain wraps a handle so that it is only called if the
+// path variables match their constraints.
+func constrain(handle httprouter.Handle, pcs []paramConstraint, router *table) httprouter.Handle {
+	if len(pcs) == 0 {
+		return handle
+	}
+	return func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+		for _, pc := range pcs {
+			if !pc.match(params.ByName(pc.name)) {
+				router.notFound(w, r)
+				return
+			}
+		}
+		handle(w, r, params)
+	}
+}
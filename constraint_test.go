@@ -0,0 +1,64 @@
+package nchi_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/muir/nchi"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConstraints(t *testing.T) {
+	mux := nchi.NewRouter(nchi.WithConstraint("even", func(s string) bool {
+		return len(s) > 0 && (s[len(s)-1]-'0')%2 == 0
+	}))
+	mux.Use("")
+	mux.Use(makeDown("a"))
+	mux.NotFound(makeDown("n"), bottom)
+	mux.Get("/thing/:thingID{int}", func(endpoint nchi.Endpoint, params nchi.Params, w http.ResponseWriter) {
+		_, _ = w.Write([]byte(string(endpoint) + " " + params.ByName("thingID")))
+	})
+	mux.Get("/u/:id{uuid}/x", makeDown("u"), bottom)
+	mux.Get("/s/:slug{[a-z-]+}", nchi.RouteName("slug"), makeDown("s"), bottom)
+	mux.Get("/e/:num{even}", makeDown("e"), bottom)
+	mux.Get("/r/:num{[0-9]{2}}", makeDown("r"), bottom)
+
+	doTest(t, mux, []testCase{
+		{path: "/thing/38", want: "/thing/:thingID{int} 38"},
+		{path: "/thing/-38", want: "/thing/:thingID{int} -38"},
+		{path: "/thing/3x", want: "an"},
+		{path: "/u/0f8fad5b-d9cb-469f-a165-70867728950e/x", want: "au"},
+		{path: "/u/0f8fad5b/x", want: "an"},
+		{path: "/s/hello-world", want: "as"},
+		{path: "/s/Hello", want: "an"},
+		{path: "/e/38", want: "ae"},
+		{path: "/e/37", want: "an"},
+		{path: "/r/12", want: "ar"},
+		{path: "/r/123", want: "an"},
+	})
+
+	routes := mux.Routes()
+	assert.Equal(t, "/thing/:thingID{int}", routes[1].Path)
+
+	u, err := mux.URL("slug", "slug", "ok-slug")
+	assert.NoError(t, err)
+	assert.Equal(t, "/s/ok-slug", u)
+	_, err = mux.URL("slug", "slug", "NOT")
+	assert.EqualError(t, err, "URL slug: value for :slug does not match {[a-z-]+}")
+}
+
+func TestConstraintErrors(t *testing.T) {
+	mux := nchi.NewRouter()
+	mux.Get("/x/:id{[a-z}", bottom)
+	assert.Error(t, mux.Bind())
+
+	mux = nchi.NewRouter()
+	mux.Get("/x/:id{[a-z]", bottom)
+	assert.EqualError(t, errors.Unwrap(mux.Bind().(nchi.BindErrors)[0]), "unterminated constraint for :id in /x/:id{[a-z]")
+
+	mux = nchi.NewRouter()
+	mux.Get("/f/*path{[a-z]+}", bottom)
+	assert.EqualError(t, errors.Unwrap(mux.Bind().(nchi.BindErrors)[0]), "constraints are not supported for *path in /f/*path{[a-z]+}")
+}
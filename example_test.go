@@ -46,17 +46,22 @@ func HandleExampleEndpoint(req ExampleRequestBundle) (nvelope.Response, error) {
 	}
 }
 
+// ServiceStack is the middleware shared by services.  The order constraints
+// are checked when the router is bound.
+var ServiceStack = nchi.NewStack("service", "1.0").
+	Add("NoLogger", nvelope.NoLogger).
+	Add("InjectWriter", nvelope.InjectWriter).
+	Add("EncodeJSON", nvelope.EncodeJSON).
+	Add("CatchPanic", nvelope.CatchPanic).
+	Add("Nil204", nvelope.Nil204).
+	Add("ReadBody", nvelope.ReadBody).
+	Add("DecodeJSON", nchi.DecodeJSON).
+	Order("InjectWriter", "EncodeJSON").
+	Order("EncodeJSON", "CatchPanic").
+	Order("ReadBody", "DecodeJSON")
+
 func Service(r *nchi.Mux) {
-	r.Use(
-		// order matters and this is a correct order
-		nvelope.NoLogger,
-		nvelope.InjectWriter,
-		nvelope.EncodeJSON,
-		nvelope.CatchPanic,
-		nvelope.Nil204,
-		nvelope.ReadBody,
-		nchi.DecodeJSON,
-	)
+	r.Use(ServiceStack)
 	r.Post("/a/path/:with/:parameters",
 		HandleExampleEndpoint,
 	)
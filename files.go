@@ -0,0 +1,283 @@
+package nchi
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/muir/nject/v2"
+	"github.com/pkg/errors"
+)
+
+// ServeFS serves files from an fs.FS, such as an embed.FS.  The path
+// must end with "/*filepath" and files are then served from the
+// matching path in fsys.  For example, if the path is "/static/*filepath"
+// a request for "/static/css/site.css" is served from "css/site.css".
+//
+// Unlike ServeFiles, ServeFS is a regular GET endpoint: the middleware
+// that is in effect for this Mux is used and additional providers may
+// be given.  When the file does not exist, the NotFound handler of the
+// router is used rather than http.NotFound.
+//
+// Files are served with strong ETags computed from their content so
+// that conditional requests can be answered with 304 (Not Modified).
+// ETags are cached and recomputed when the modification time or the
+// size of a file changes.
+func (mux *Mux) ServeFS(path string, fsys fs.FS, providers ...interface{}) {
+	mux.add(&Mux{
+		path:      path,
+		method:    http.MethodGet,
+		providers: nject.Sequence(path, translateMiddleware(providers)...),
+		stacks:    stacksIn(providers),
+		files:     &fileServer{fsys: fsys},
+	})
+}
+
+// ServeSPA serves a single page application from an fs.FS.  It is
+// like ServeFS with the following differences:
+//
+// Requests for paths that do not exist and do not have a file extension
+// are answered with the index.html at the root of fsys so that the
+// application can do its own routing.  Directories are also answered with
+// the root index.html.  Requests for missing paths that have a file
+// extension use the NotFound handler.
+//
+// When the client accepts gzip encoding and there is a file with the
+// same name plus ".gz", the compressed file is served instead.
+//
+// Files with fingerprinted names, like "main.3f2a9c1b.js", are served
+// with headers that allow them to be cached forever.  A name is considered
+// fingerprinted if the last dot- or dash-separated part before the
+// extension is at least eight letters, digits, or underscores and includes
+// at least one digit.  The index.html is served with "Cache-Control: no-cache".
+func (mux *Mux) ServeSPA(path string, fsys fs.FS, providers ...interface{}) {
+	mux.add(&Mux{
+		path:      path,
+		method:    http.MethodGet,
+		providers: nject.Sequence(path, translateMiddleware(providers)...),
+		stacks:    stacksIn(providers),
+		files:     &fileServer{fsys: fsys, spa: true},
+	})
+}
+
+const (
+	immutableCacheControl = "public, max-age=31536000, immutable"
+	spaIndex              = "index.html"
+)
+
+type fileServer struct {
+	fsys  fs.FS
+	spa   bool
+	etags sync.Map // file name to fileETag
+}
+
+// fileETag is a cached ETag.  It is valid while the modification time
+// and size of the file are unchanged.
+type fileETag struct {
+	modTime time.Time
+	size    int64
+	etag    string
+}
+
+var fingerprintRE = regexp.MustCompile(`[.-]([A-Za-z0-9_]*[0-9][A-Za-z0-9_]*)\.[^./]+$`)
+
+func (l *leaf) bindFiles(router *table, combinedPath string, pcs []paramConstraint, combinedProviders *nject.Collection) error {
+	if !strings.HasSuffix(combinedPath, "/*filepath") {
+		return errors.New("path must end with /*filepath")
+	}
+	files := l.mux.files
+	handler := http.FileServer(http.FS(files.fsys))
+	var handle httprouter.Handle
+	err := combinedProviders.Append("ServeFS", func(w http.ResponseWriter, r *http.Request, params Params) {
+		filepath := params[len(params)-1].Value
+		if files.spa {
+			files.serveSPA(w, r, filepath, router.notFound)
+			return
+		}
+		name := fsName(filepath)
+		info, err := fs.Stat(files.fsys, name)
+		if notExist(err) {
+			router.notFound(w, r)
+			return
+		}
+		if err == nil && !info.IsDir() {
+			if etag := files.etag(name, info); etag != "" {
+				w.Header().Set("ETag", etag)
+			}
+		}
+		handler.ServeHTTP(w, stripPrefix(r, filepath))
+	}).Bind(&handle, nil)
+	if err != nil {
+		return err
+	}
+	return router.handle(l, l.mux.method, combinedPath, constrain(handle, pcs, router))
+}
+
+func (files *fileServer) serveSPA(w http.ResponseWriter, r *http.Request, requestPath string, notFound http.HandlerFunc) {
+	name := fsName(requestPath)
+	info, err := fs.Stat(files.fsys, name)
+	switch {
+	case err == nil && !info.IsDir():
+		if fingerprinted(name) {
+			w.Header().Set("Cache-Control", immutableCacheControl)
+		}
+		files.serveFile(w, r, name, notFound)
+	case err == nil || (notExist(err) && path.Ext(name) == ""):
+		w.Header().Set("Cache-Control", "no-cache")
+		files.serveFile(w, r, spaIndex, notFound)
+	case notExist(err):
+		notFound(w, r)
+	default:
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
+
+// serveFile serves a single file, preferring a gzipped sibling
+// if the client accepts gzip.
+func (files *fileServer) serveFile(w http.ResponseWriter, r *http.Request, name string, notFound http.HandlerFunc) {
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	if _, err := fs.Stat(files.fsys, name+".gz"); err == nil {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if acceptsEncoding(r, "gzip") {
+			f, content, info, err := openFile(files.fsys, name+".gz")
+			if err == nil {
+				defer f.Close()
+				w.Header().Set("Content-Type", contentType)
+				w.Header().Set("Content-Encoding", "gzip")
+				w.Header().Set("ETag", files.etag(name+".gz", info))
+				http.ServeContent(w, r, name, info.ModTime(), content)
+				return
+			}
+		}
+	}
+	f, content, info, err := openFile(files.fsys, name)
+	if err != nil {
+		if notExist(err) {
+			w.Header().Del("Cache-Control")
+			notFound(w, r)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("ETag", files.etag(name, info))
+	http.ServeContent(w, r, name, info.ModTime(), content)
+}
+
+// etag returns the ETag for a file, computing it from the content
+// of the file the first time and again whenever the modification time
+// or size of the file differs from when it was computed.  The ETag is
+// empty if the file cannot be read.
+func (files *fileServer) etag(name string, info fs.FileInfo) string {
+	if cached, ok := files.etags.Load(name); ok {
+		c := cached.(fileETag)
+		if c.modTime.Equal(info.ModTime()) && c.size == info.Size() {
+			return c.etag
+		}
+		files.etags.Delete(name)
+	}
+	f, err := files.fsys.Open(name)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	etag, err := contentETag(f)
+	if err != nil {
+		return ""
+	}
+	files.etags.Store(name, fileETag{
+		modTime: info.ModTime(),
+		size:    info.Size(),
+		etag:    etag,
+	})
+	return etag
+}
+
+// openFile opens a file for http.ServeContent.  The returned file must
+// be closed.  The content is the file itself if it can seek.  Files that
+// cannot seek are read into memory.
+func openFile(fsys fs.FS, name string) (fs.File, io.ReadSeeker, fs.FileInfo, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, nil, nil, err
+	}
+	if content, ok := f.(io.ReadSeeker); ok {
+		return f, content, info, nil
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, nil, nil, err
+	}
+	return f, bytes.NewReader(data), info, nil
+}
+
+// httpFS adapts an http.FileSystem to be an fs.FS
+type httpFS struct {
+	root http.FileSystem
+}
+
+func (h httpFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	return h.root.Open("/" + name)
+}
+
+// acceptsEncoding reports if the Accept-Encoding header of a request
+// allows a content coding
+func acceptsEncoding(r *http.Request, coding string) bool {
+	for _, header := range r.Header.Values("Accept-Encoding") {
+		for _, part := range strings.Split(header, ",") {
+			fields := strings.Split(part, ";")
+			if !strings.EqualFold(strings.TrimSpace(fields[0]), coding) {
+				continue
+			}
+			for _, param := range fields[1:] {
+				param = strings.ReplaceAll(strings.TrimSpace(param), " ", "")
+				if strings.HasPrefix(param, "q=") && strings.Trim(param[2:], "0.") == "" {
+					return false
+				}
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// fingerprinted reports if a file name includes a content hash
+func fingerprinted(name string) bool {
+	m := fingerprintRE.FindStringSubmatch(path.Base(name))
+	return m != nil && len(m[1]) >= 8
+}
+
+func notExist(err error) bool {
+	return errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrInvalid)
+}
+
+// fsName converts a request path into an fs.FS name
+func fsName(requestPath string) string {
+	name := strings.TrimPrefix(path.Clean("/"+requestPath), "/")
+	if name == "" {
+		return "."
+	}
+	return name
+}
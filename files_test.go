@@ -0,0 +1,211 @@
+package nchi_test
+
+import (
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/muir/nchi"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServeFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"hello.txt":      {Data: []byte("hello-")},
+		"css/site.css":   {Data: []byte("body{}-")},
+		"sub/index.html": {Data: []byte("index-")},
+	}
+	mux := nchi.NewRouter()
+	mux.Use("")
+	mux.Use(makeUp1("a"))
+	mux.NotFound(makeDown("n"), bottom)
+	mux.Route("/static", func(mux *nchi.Mux) {
+		mux.ServeFS("/*filepath", fsys, makeUp1("b"))
+	})
+
+	doTest(t, mux, []testCase{
+		{path: "/static/hello.txt", want: "hello-ba"},
+		{path: "/static/css/site.css", want: "body{}-ba"},
+		{path: "/static/sub/", want: "index-ba"},
+		{path: "/static/missing.txt", want: "naba"},
+		{path: "/static/../hello.txt", want: "hello-ba"},
+	})
+}
+
+func TestServeFSBadPath(t *testing.T) {
+	mux := nchi.NewRouter()
+	mux.ServeFS("/static", fstest.MapFS{})
+	assert.Error(t, mux.Bind())
+}
+
+func TestServeSPA(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.html":               {Data: []byte("index")},
+		"assets/main.3f2a9c1b.js":  {Data: []byte("main")},
+		"assets/app.js":            {Data: []byte("app")},
+		"assets/app.js.gz":         {Data: []byte("gzipped app")},
+		"assets/react-dom.prod.js": {Data: []byte("react")},
+		"assets/empty/.keep":       {Data: []byte{}},
+	}
+	mux := nchi.NewRouter()
+	mux.Use("")
+	mux.NotFound(makeDown("n"), bottom)
+	mux.Get("/api/x", bottom)
+	mux.ServeSPA("/app/*filepath", fsys)
+
+	cases := []struct {
+		path         string
+		gzip         bool
+		wantBody     string
+		wantType     string
+		wantCache    string
+		wantEncoding string
+	}{
+		{path: "/app/", wantBody: "index", wantType: "text/html", wantCache: "no-cache"},
+		{path: "/app/users/7", wantBody: "index", wantType: "text/html", wantCache: "no-cache"},
+		{path: "/app/assets/empty", wantBody: "index", wantType: "text/html", wantCache: "no-cache"},
+		{path: "/app/assets/missing.js", wantBody: "n"},
+		{path: "/app/assets/main.3f2a9c1b.js", wantBody: "main", wantType: "javascript", wantCache: "public, max-age=31536000, immutable"},
+		{path: "/app/assets/react-dom.prod.js", wantBody: "react", wantType: "javascript"},
+		{path: "/app/assets/app.js", wantBody: "app", wantType: "javascript"},
+		{path: "/app/assets/app.js", gzip: true, wantBody: "gzipped app", wantType: "javascript", wantEncoding: "gzip"},
+		{path: "/api/missing", wantBody: "n"},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("GET", tc.path, nil)
+			if tc.gzip {
+				r.Header.Set("Accept-Encoding", "br, gzip;q=0.8")
+			}
+			mux.ServeHTTP(w, r)
+			assert.Equal(t, tc.wantBody, w.Body.String(), "body")
+			if tc.wantType != "" {
+				assert.Contains(t, w.Header().Get("Content-Type"), tc.wantType, "content type")
+			}
+			assert.Equal(t, tc.wantCache, w.Header().Get("Cache-Control"), "cache control")
+			assert.Equal(t, tc.wantEncoding, w.Header().Get("Content-Encoding"), "content encoding")
+		})
+	}
+}
+
+// noSeekFS hides the Seek method of the files it opens
+type noSeekFS struct{ fs.FS }
+
+type noSeekFile struct{ fs.File }
+
+func (fsys noSeekFS) Open(name string) (fs.File, error) {
+	f, err := fsys.FS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return noSeekFile{File: f}, nil
+}
+
+func TestServeSPARange(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.html":  {Data: []byte("index")},
+		"app.js":      {Data: []byte("0123456789")},
+		"other.js":    {Data: []byte("abcdefghij")},
+		"other.js.gz": {Data: []byte("ABCDEFGHIJ")},
+	}
+	for name, fsys := range map[string]fs.FS{
+		"seek":    fsys,
+		"no seek": noSeekFS{FS: fsys},
+	} {
+		fsys := fsys
+		t.Run(name, func(t *testing.T) {
+			mux := nchi.NewRouter()
+			mux.ServeSPA("/*filepath", fsys)
+			for path, want := range map[string]string{
+				"/app.js":   "234",
+				"/other.js": "CDE",
+			} {
+				w := httptest.NewRecorder()
+				r := httptest.NewRequest("GET", path, nil)
+				r.Header.Set("Range", "bytes=2-4")
+				r.Header.Set("Accept-Encoding", "gzip")
+				mux.ServeHTTP(w, r)
+				assert.Equal(t, 206, w.Code, path)
+				assert.Equal(t, want, w.Body.String(), path)
+				assert.NotEmpty(t, w.Header().Get("ETag"), path)
+			}
+		})
+	}
+}
+
+func TestServeFilesETag(t *testing.T) {
+	mux := nchi.NewRouter()
+	mux.ServeFiles("/files/*filepath", http.FS(fstest.MapFS{
+		"a.txt": {Data: []byte("file a")},
+	}))
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/files/a.txt", nil))
+	assert.Equal(t, "file a", w.Body.String())
+	etag := w.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/files/a.txt", nil)
+	r.Header.Set("If-None-Match", etag)
+	mux.ServeHTTP(w, r)
+	assert.Equal(t, 304, w.Code)
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/files/missing.txt", nil))
+	assert.Equal(t, 404, w.Code)
+	assert.Empty(t, w.Header().Get("ETag"))
+}
+
+func TestServeFSChangedFile(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.txt")
+	mux := nchi.NewRouter()
+	mux.ServeFS("/fs/*filepath", os.DirFS(dir))
+	mux.ServeSPA("/spa/*filepath", os.DirFS(dir))
+
+	get := func(path string, ifNoneMatch string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", path, nil)
+		if ifNoneMatch != "" {
+			r.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		mux.ServeHTTP(w, r)
+		return w
+	}
+
+	modified := time.Now().Add(-time.Hour)
+	write := func(content string) {
+		require.NoError(t, os.WriteFile(file, []byte(content), 0o600))
+		modified = modified.Add(time.Minute)
+		require.NoError(t, os.Chtimes(file, modified, modified))
+	}
+
+	write("first")
+	var etags []string
+	for _, path := range []string{"/fs/a.txt", "/spa/a.txt"} {
+		w := get(path, "")
+		assert.Equal(t, "first", w.Body.String(), path)
+		etag := w.Header().Get("ETag")
+		assert.NotEmpty(t, etag, path)
+		assert.Equal(t, 304, get(path, etag).Code, path)
+		etags = append(etags, etag)
+	}
+
+	write("second version")
+	for i, path := range []string{"/fs/a.txt", "/spa/a.txt"} {
+		w := get(path, etags[i])
+		assert.Equal(t, 200, w.Code, path)
+		assert.Equal(t, "second version", w.Body.String(), path)
+		assert.NotEqual(t, etags[i], w.Header().Get("ETag"), path)
+	}
+}
@@ -0,0 +1,99 @@
+package nchi
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// addAutoHEAD registers HEAD handlers for the GET routes that have
+// WithAutoHEAD in effect and do not have a HEAD route of their own.
+func (t *table) addAutoHEAD() {
+	explicit := make(map[string]bool)
+	for _, reg := range t.registered {
+		if reg.method == http.MethodHead {
+			explicit[reg.path] = true
+		}
+	}
+	for _, reg := range t.registered {
+		if reg.method != http.MethodGet || explicit[reg.path] || !applyOptions(reg.leaf.options).autoHEAD {
+			continue
+		}
+		handle := headHandle(reg.handle)
+		path := reg.path
+		if catchPanic(func() { t.Router.Handle(http.MethodHead, path, handle) }) != nil {
+			// an explicit HEAD route covers this path
+			continue
+		}
+		reg.method = http.MethodHead
+		reg.handle = handle
+		t.registered = append(t.registered, reg)
+	}
+}
+
+func headHandle(handle httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+		hw := &headWriter{ResponseWriter: w}
+		handle(hw, r, params)
+		hw.finish()
+	}
+}
+
+// headWriter discards the body and delays writing the
+// header so that Content-Length can be set.
+type headWriter struct {
+	http.ResponseWriter
+	status  int
+	length  int
+	flushed bool
+}
+
+func (hw *headWriter) WriteHeader(status int) {
+	if hw.status == 0 {
+		hw.status = status
+	}
+}
+
+func (hw *headWriter) Write(b []byte) (int, error) {
+	if hw.status == 0 {
+		hw.status = http.StatusOK
+	}
+	hw.length += len(b)
+	return len(b), nil
+}
+
+// Flush writes the header, without Content-Length since the length
+// of a streamed response is not known, and flushes it.
+func (hw *headWriter) Flush() {
+	if !hw.flushed {
+		if hw.status == 0 {
+			hw.status = http.StatusOK
+		}
+		hw.flushed = true
+		hw.ResponseWriter.WriteHeader(hw.status)
+	}
+	if f, ok := hw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap allows http.ResponseController to reach the underlying
+// http.ResponseWriter
+func (hw *headWriter) Unwrap() http.ResponseWriter {
+	return hw.ResponseWriter
+}
+
+func (hw *headWriter) finish() {
+	if hw.flushed {
+		return
+	}
+	if hw.status == 0 {
+		hw.status = http.StatusOK
+	}
+	h := hw.ResponseWriter.Header()
+	if h.Get("Content-Length") == "" && hw.status >= 200 && hw.status != http.StatusNoContent && hw.status != http.StatusNotModified {
+		h.Set("Content-Length", strconv.Itoa(hw.length))
+	}
+	hw.ResponseWriter.WriteHeader(hw.status)
+}
@@ -0,0 +1,92 @@
+package nchi
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/muir/nject/v2"
+)
+
+// HostParams is a type that handlers can accept as an input.  It holds
+// the values of the variables in the host pattern given to Host.  If you
+// have
+//
+//	mux.Host(":tenant.example.com", func(mux *nchi.Mux) {
+//		mux.Get("/thing", handler)
+//	})
+//
+// and handler takes an nchi.HostParams argument, and there is a request for
+// http://acme.example.com/thing, then HostParams.ByName("tenant") will be "acme".
+// HostParams can only be injected into routes that are inside a Host.
+type HostParams httprouter.Params
+
+// ByName returns the value of the first HostParam which key matches the
+// given name.  If no matching HostParam is found, an empty string is returned.
+func (hp HostParams) ByName(name string) string {
+	return httprouter.Params(hp).ByName(name)
+}
+
+// Host establishes a new Mux that only handles requests for hosts that
+// match the host pattern.  Each host pattern has its own route tree.  Requests
+// for hosts that do not match any host pattern use the routes that are not
+// inside any Host.  Middleware is inherited as with Route.
+//
+// Host patterns are matched case-insensitively against the request host
+// with any port removed.  Labels of the pattern that start with ":" match
+// any single label of the request host and their values are available by
+// injecting HostParams.  When more than one host pattern matches,
+// patterns with fewer variables are preferred and then patterns that were
+// registered first.
+//
+//	mux.Host("api.example.com", func(mux *nchi.Mux) { ... })
+//	mux.Host(":tenant.example.com", func(mux *nchi.Mux) { ... })
+//
+// Host cannot be used inside another Host: Bind reports an error for
+// each route of the inner Host.
+func (mux *Mux) Host(pattern string, f func(mux *Mux)) {
+	f(mux.add(&Mux{
+		providers: nject.Sequence(mux.path),
+		kind:      "Host",
+		host:      strings.ToLower(pattern),
+	}))
+}
+
+// match returns nil if the host does not match.  It returns non-nil
+// if the host matches.
+func matchHost(labels []string, host string) HostParams {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	got := strings.Split(strings.ToLower(host), ".")
+	if len(got) != len(labels) {
+		return nil
+	}
+	params := HostParams{}
+	for i, label := range labels {
+		switch {
+		case strings.HasPrefix(label, ":"):
+			if got[i] == "" {
+				return nil
+			}
+			params = append(params, httprouter.Param{Key: label[1:], Value: got[i]})
+		case label != got[i]:
+			return nil
+		}
+	}
+	return params
+}
+
+// hostParams returns a provider for HostParams.  It is only
+// consumed if a handler or middleware wants HostParams.  Outside
+// of Host, there is no such provider.
+func (l *leaf) hostParams() *nject.Collection {
+	if l.host == "" {
+		return nject.Sequence("HostParams")
+	}
+	labels := strings.Split(l.host, ".")
+	return nject.Sequence("HostParams", func(r *http.Request) HostParams {
+		return matchHost(labels, r.Host)
+	})
+}
@@ -0,0 +1,68 @@
+package nchi_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/muir/nchi"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHost(t *testing.T) {
+	mux := nchi.NewRouter()
+	mux.Use("")
+	mux.Use(makeDown("a"))
+	mux.Get("/x", makeDown("b"), bottom)
+	mux.NotFound(makeDown("n"), bottom)
+	mux.Host(":tenant.example.com", func(mux *nchi.Mux) {
+		mux.Get("/x", func(hp nchi.HostParams, w http.ResponseWriter) {
+			_, _ = w.Write([]byte("tenant " + hp.ByName("tenant")))
+		})
+	})
+	mux.Host("api.example.com", func(mux *nchi.Mux) {
+		mux.Use(makeDown("c"))
+		mux.Get("/x", bottom)
+	})
+
+	cases := []struct {
+		url  string
+		want string
+	}{
+		{url: "http://example.com/x", want: "ab"},
+		{url: "http://api.example.com/x", want: "ac"},
+		{url: "http://API.example.com:8080/x", want: "ac"},
+		{url: "http://acme.example.com/x", want: "tenant acme"},
+		{url: "http://acme.example.com/y", want: "an"},
+		{url: "http://a.b.example.com/x", want: "ab"},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", tc.url, nil)
+		mux.ServeHTTP(w, r)
+		body, err := io.ReadAll(w.Result().Body)
+		assert.NoError(t, err, tc.url)
+		assert.Equal(t, tc.want, string(body), tc.url)
+	}
+}
+
+func TestHostErrors(t *testing.T) {
+	mux := nchi.NewRouter()
+	mux.Get("/x", func(hp nchi.HostParams) {})
+	assert.Error(t, mux.Bind(), "HostParams outside Host")
+
+	mux = nchi.NewRouter()
+	mux.Host("a.example.com", func(mux *nchi.Mux) {
+		mux.Host("b.example.com", func(mux *nchi.Mux) {
+			mux.Get("/x", func() {})
+		})
+	})
+	err := mux.Bind()
+	var bindError *nchi.BindError
+	if assert.ErrorAs(t, err, &bindError) {
+		assert.Equal(t, "/x", bindError.Path)
+		assert.EqualError(t, bindError.Cause, "Host b.example.com cannot be used inside Host a.example.com")
+	}
+}
@@ -0,0 +1,223 @@
+package nchi
+
+import (
+	"context"
+	"net/http"
+	"sort"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// Matcher can be passed as one of the providers to Method, Get,
+// Post, etc.  It is not injected.  Instead it adds a requirement
+// that requests must meet, in addition to the method and path, for
+// the route to handle them.  Create Matchers with Header, Query, and
+// Match.
+//
+// Matchers allow more than one route to share the same method and
+// path.  When a request arrives for such a path, the routes are tried in
+// the order they were registered and the first route whose Matchers all
+// match handles the request.  A route without Matchers matches every
+// request.  The exception is Produces: when routes match with Produces,
+// the route that offers the media type the client prefers is used.
+//
+// If no route matches, the response is 415 (Unsupported Media Type)
+// when a Consumes Matcher was not met, 406 (Not Acceptable) when a
+// Header or Produces Matcher was not met, and otherwise the NotFound
+// handler is used.
+//
+//	mux.Get("/report", nchi.Query("format", "csv"), reportCSV)
+//	mux.Get("/report", nchi.Header("X-Api-Version", "2"), reportV2)
+//	mux.Get("/report", report)
+type Matcher struct {
+	name     string
+	status   int // the response status when no route matches because of this Matcher
+	match    func(*http.Request) bool
+	produces []string // set for Produces only
+	err      error    // reported by Bind
+}
+
+// Header returns a Matcher that requires that a request have a
+// header with a particular value.
+func Header(name string, value string) Matcher {
+	name = http.CanonicalHeaderKey(name)
+	return Matcher{
+		name:   "Header(" + name + "=" + value + ")",
+		status: http.StatusNotAcceptable,
+		match: func(r *http.Request) bool {
+			for _, v := range r.Header[name] {
+				if v == value {
+					return true
+				}
+			}
+			return false
+		},
+	}
+}
+
+// Query returns a Matcher that requires that a request have a
+// query parameter with a particular value.
+func Query(name string, value string) Matcher {
+	return Matcher{
+		name: "Query(" + name + "=" + value + ")",
+		match: func(r *http.Request) bool {
+			for _, v := range r.URL.Query()[name] {
+				if v == value {
+					return true
+				}
+			}
+			return false
+		},
+	}
+}
+
+// Match returns a Matcher that uses an arbitrary predicate.
+func Match(match func(*http.Request) bool) Matcher {
+	return Matcher{
+		name:  "Match",
+		match: match,
+	}
+}
+
+// checkMatchers returns the first error from the Matchers of a leaf
+func (l *leaf) checkMatchers() error {
+	for _, m := range l.mux.matchers {
+		if m.err != nil {
+			return m.err
+		}
+	}
+	return nil
+}
+
+// String returns a description of the Matcher
+func (m Matcher) String() string { return m.name }
+
+// matchGroup is the set of routes that share a method and path
+type matchGroup struct {
+	router  *table
+	members []matchMember
+}
+
+type matchMember struct {
+	matchers []Matcher
+	version  string
+	handle   httprouter.Handle
+}
+
+func (g *matchGroup) serve(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	best := -1
+	var bestQuality float64
+	var bestType string
+	var status int
+	for i, member := range g.members {
+		quality, mediaType, failed, ok := member.evaluate(r)
+		if !ok {
+			// 415 is preferred to 406 is preferred to NotFound
+			if failed > status {
+				status = failed
+			}
+			continue
+		}
+		if quality > bestQuality {
+			best, bestQuality, bestType = i, quality, mediaType
+			if quality == 1 {
+				break
+			}
+		}
+	}
+	switch {
+	case best != -1:
+		if bestType != "" {
+			r = r.WithContext(context.WithValue(r.Context(), mediaTypeKey{}, MediaType(bestType)))
+		}
+		g.members[best].handle(w, r, params)
+	case status != 0:
+		http.Error(w, http.StatusText(status), status)
+	default:
+		g.router.notFound(w, r)
+	}
+}
+
+// evaluate checks the Matchers of a member.  The quality is the q-value of
+// the negotiated media type, or 1 if there is no Produces Matcher.  If the
+// Matchers are not met, the status is the response status for that.
+func (member matchMember) evaluate(r *http.Request) (quality float64, mediaType string, status int, ok bool) {
+	quality = 1
+	for _, m := range member.matchers {
+		if m.produces != nil {
+			mediaType, quality = negotiate(r.Header.Values("Accept"), m.produces)
+			if quality == 0 {
+				return 0, "", http.StatusNotAcceptable, false
+			}
+			continue
+		}
+		if !m.match(r) {
+			return 0, "", m.status, false
+		}
+	}
+	return quality, mediaType, 0, true
+}
+
+// matchKey identifies the routes that share a method and path
+func matchKey(method, path string) string {
+	return method + " " + path
+}
+
+// findMatched notes the methods and paths that have routes with
+// Matchers so that all the routes for those can be grouped.
+func (mux *Mux) findMatched(b *bound) {
+	_ = mux.walkAll(func(l *leaf) error {
+		if len(l.matchers()) == 0 || l.mux.method == anyMethod {
+			return nil
+		}
+		path, _, err := l.routerPattern(constraintsFor(l.options))
+		if err != nil {
+			// reported when binding
+			return nil
+		}
+		b.tableFor(l.host, mux.options).matched[matchKey(l.mux.method, path)] = nil
+		return nil
+	})
+}
+
+// handleMatched registers a route that shares its method and path
+// with routes that have Matchers.
+func (t *table) handleMatched(l *leaf, method, path string, handle httprouter.Handle) error {
+	key := matchKey(method, path)
+	group := t.matched[key]
+	if group == nil {
+		group = &matchGroup{router: t}
+		err := t.handle(l, method, path, group.serve)
+		if err != nil {
+			return err
+		}
+		t.matched[key] = group
+	}
+	group.members = append(group.members, matchMember{
+		matchers: l.matchers(),
+		version:  l.version,
+		handle:   handle,
+	})
+	// later versions are tried first
+	sort.SliceStable(group.members, func(i, j int) bool {
+		return compareVersions(group.members[i].version, group.members[j].version) > 0
+	})
+	return nil
+}
+
+// matchOnly returns a handle that applies the Matchers of
+// a route that does not share its method and path.
+func (t *table) matchOnly(l *leaf, handle httprouter.Handle) httprouter.Handle {
+	matchers := l.matchers()
+	if len(matchers) == 0 {
+		return handle
+	}
+	group := &matchGroup{
+		router: t,
+		members: []matchMember{{
+			matchers: matchers,
+			handle:   handle,
+		}},
+	}
+	return group.serve
+}
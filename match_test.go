@@ -0,0 +1,65 @@
+package nchi_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/muir/nchi"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchers(t *testing.T) {
+	mux := nchi.NewRouter()
+	mux.Use("")
+	mux.Get("/report", nchi.Query("format", "csv"), makeDown("csv"), bottom)
+	mux.Get("/report", nchi.Header("x-api-version", "2"), makeDown("v2"), bottom)
+	mux.Get("/report", makeDown("default"), bottom)
+	mux.Get("/v", nchi.Header("X-Api-Version", "2"), makeDown("v2"), bottom)
+	mux.Get("/q", nchi.Query("a", "b"), makeDown("q"), bottom)
+	mux.Get("/p", nchi.Match(func(r *http.Request) bool {
+		return r.URL.Query().Has("yes")
+	}), makeDown("p"), bottom)
+	mux.Post("/p", makeDown("post"), bottom)
+
+	cases := []struct {
+		path    string
+		version string
+		status  int
+		want    string
+	}{
+		{path: "/report?format=csv", version: "2", status: 200, want: "csv"},
+		{path: "/report", version: "2", status: 200, want: "v2"},
+		{path: "/report", status: 200, want: "default"},
+		{path: "/v", version: "2", status: 200, want: "v2"},
+		{path: "/v", version: "1", status: 406, want: "Not Acceptable\n"},
+		{path: "/q?a=b", status: 200, want: "q"},
+		{path: "/q?a=c", status: 404, want: "404 page not found\n"},
+		{path: "/p?yes", status: 200, want: "p"},
+		{path: "/p", status: 404, want: "404 page not found\n"},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.path+" "+tc.version, func(t *testing.T) {
+			r := httptest.NewRequest("GET", tc.path, nil)
+			if tc.version != "" {
+				r.Header.Set("X-Api-Version", tc.version)
+			}
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, r)
+			assert.Equal(t, tc.status, w.Code)
+			assert.Equal(t, tc.want, w.Body.String())
+		})
+	}
+	doTestMethod(t, mux, "POST", []testCase{
+		{path: "/p", want: "post"},
+	})
+
+	routes := mux.Routes()
+	if assert.True(t, len(routes) > 2) {
+		assert.Equal(t, []string{"Query(format=csv)"}, routes[0].Matchers)
+		assert.Equal(t, []string{"Header(X-Api-Version=2)"}, routes[1].Matchers)
+		assert.Nil(t, routes[2].Matchers)
+	}
+}
@@ -37,6 +37,8 @@ func translateMiddleware(raw []interface{}) []interface{} {
 			n = append(n, nvelope.MiddlewareBaseWriter(hfs...))
 			i = j - 1
 			hfs = hfs[:0]
+		} else if s, ok := raw[i].(*Stack); ok {
+			n = append(n, s.layers)
 		} else {
 			n = append(n, raw[i])
 		}
@@ -0,0 +1,147 @@
+package nchi
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/muir/nject/v2"
+	"github.com/pkg/errors"
+)
+
+// mountMethods are the methods that MountHandler registers.  Requests
+// with other methods reach the handler through the fallback routes.
+var mountMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodConnect,
+	http.MethodOptions,
+	http.MethodTrace,
+}
+
+// Mount attaches another Mux at a new path (combined with the current
+// path context).  The routes, middleware, and special handlers (NotFound,
+// PanicHandler, etc) of the mounted Mux are grafted into this Mux when
+// this Mux is bound.  The middleware that is in effect for this Mux at
+// the time Mount is called wraps the middleware of the mounted Mux.
+//
+// Options given to NewRouter for the mounted Mux are ignored.
+//
+// Since the mounted Mux is only examined at Bind time, routes may be
+// added to it after the call to Mount.  A Mux can be mounted more
+// than once but not inside itself: Bind fails if the Muxes mount
+// each other in a cycle.
+func (mux *Mux) Mount(path string, sub *Mux) {
+	mux.add(&Mux{
+		path:      path,
+		providers: nject.Sequence(path),
+		mounted:   sub,
+		kind:      "Mount",
+	})
+}
+
+// MountHandler attaches an http.Handler at a new path (combined with the
+// current path context).  All requests, of any method, for that
+// path or for paths below it are sent to the handler.  The path prefix
+// is stripped from the request URL before calling the handler so that a
+// request for "/prefix/foo" is seen by the handler as "/foo".
+//
+// Middleware that is in effect for this Mux at the time MountHandler is
+// called is used.
+func (mux *Mux) MountHandler(path string, handler http.Handler) {
+	mux.add(&Mux{
+		path:      path,
+		providers: nject.Sequence(path),
+		handler:   handler,
+	})
+}
+
+// mountCycles reports Mounts that mount a Mux inside itself.  Mounting
+// lists the Muxes that are being mounted, outermost first.
+func (mux *Mux) mountCycles(path string, mounting []*Mux) BindErrors {
+	var errs BindErrors
+	for _, route := range mux.routes {
+		combinedPath := path + route.path
+		switch {
+		case route.mounted == nil:
+			errs = append(errs, route.mountCycles(combinedPath, mounting)...)
+		case containsMux(mounting, route.mounted):
+			errs = append(errs, &BindError{
+				Path:         combinedPath,
+				Special:      "Mount",
+				Cause:        errors.New("Mount creates a cycle"),
+				RegisteredAt: route.registeredAt,
+			})
+		default:
+			errs = append(errs, route.mounted.mountCycles(combinedPath,
+				append(mounting[:len(mounting):len(mounting)], route.mounted))...)
+		}
+	}
+	return errs
+}
+
+func containsMux(muxes []*Mux, mux *Mux) bool {
+	for _, m := range muxes {
+		if m == mux {
+			return true
+		}
+	}
+	return false
+}
+
+const mountParam = "mountPath"
+
+func (l *leaf) bindHandler(router *table, combinedPath string, pcs []paramConstraint, combinedProviders *nject.Collection) error {
+	mux := l.mux
+	prefix := strings.TrimSuffix(combinedPath, "/")
+	var exact, below httprouter.Handle
+	err := combinedProviders.Append("exact", func(w http.ResponseWriter, r *http.Request) {
+		mux.handler.ServeHTTP(w, stripPrefix(r, "/"))
+	}).Bind(&exact, nil)
+	if err != nil {
+		return err
+	}
+	err = combinedProviders.Append("below", func(w http.ResponseWriter, r *http.Request, params Params) {
+		mux.handler.ServeHTTP(w, stripPrefix(r, params[len(params)-1].Value))
+	}).Bind(&below, nil)
+	if err != nil {
+		return err
+	}
+	for _, method := range mountMethods {
+		if prefix != "" {
+			err := router.handle(l, method, prefix, constrain(exact, pcs, router))
+			if err != nil {
+				return err
+			}
+		}
+		err := router.handle(l, method, prefix+"/*"+mountParam, constrain(below, pcs, router))
+		if err != nil {
+			return err
+		}
+	}
+	// other methods, like PROPFIND, are sent to the handler too
+	if prefix != "" {
+		err := router.addFallback(prefix, constrain(exact, pcs, router))
+		if err != nil {
+			return err
+		}
+	}
+	return router.addFallback(prefix+"/*"+mountParam, constrain(below, pcs, router))
+}
+
+// stripPrefix returns a shallow copy of the request with the
+// path replaced.
+func stripPrefix(r *http.Request, path string) *http.Request {
+	r2 := new(http.Request)
+	*r2 = *r
+	r2.URL = new(url.URL)
+	*r2.URL = *r.URL
+	r2.URL.Path = path
+	r2.URL.RawPath = ""
+	return r2
+}
@@ -0,0 +1,100 @@
+package nchi_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/muir/nchi"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMount(t *testing.T) {
+	admin := nchi.NewRouter()
+	admin.Use(makeDown("b"))
+	admin.Get("/users", makeDown("c"), bottom)
+	admin.Route("/r", func(r *nchi.Mux) {
+		r.Get("/:id", func(endpoint nchi.Endpoint, w http.ResponseWriter) {
+			_, _ = w.Write([]byte(endpoint))
+		})
+	})
+	admin.NotFound(makeDown("n"), bottom)
+
+	mux := nchi.NewRouter()
+	mux.Use("")
+	mux.Use(makeDown("a"))
+	mux.Mount("/admin", admin)
+	mux.Use(makeDown("x"))
+	mux.Get("/d", bottom)
+
+	admin.Get("/late", bottom)
+
+	doTest(t, mux, []testCase{
+		{path: "/admin/users", want: "abc"},
+		{path: "/admin/late", want: "ab"},
+		{path: "/admin/r/7", want: "/admin/r/:id"},
+		{path: "/d", want: "ax"},
+		{path: "/admin/missing", want: "abn"},
+		{path: "/missing", want: "404 page not found\n"},
+	})
+}
+
+func TestMountHandler(t *testing.T) {
+	mux := nchi.NewRouter()
+	mux.Use("")
+	mux.Use(makeUp1("a"))
+	mux.Route("/r", func(r *nchi.Mux) {
+		r.MountHandler("/files", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(r.Method + " " + r.URL.Path + "-"))
+		}))
+	})
+
+	doTest(t, mux, []testCase{
+		{path: "/r/files", want: "GET /-a"},
+		{path: "/r/files/", want: "GET /-a"},
+		{path: "/r/files/x/y", want: "GET /x/y-a"},
+	})
+	doTestMethod(t, mux, "DELETE", []testCase{
+		{path: "/r/files/z", want: "DELETE /z-a"},
+	})
+	doTestMethod(t, mux, "PROPFIND", []testCase{
+		{path: "/r/files", want: "PROPFIND /-a"},
+		{path: "/r/files/z", want: "PROPFIND /z-a"},
+		{path: "/r/other", want: "404 page not found\n"},
+	})
+}
+
+func TestMountCycle(t *testing.T) {
+	self := nchi.NewRouter()
+	self.Get("/x", func() {})
+	self.Mount("/self", self)
+
+	a := nchi.NewRouter()
+	b := nchi.NewRouter()
+	a.Get("/x", func() {})
+	a.Mount("/b", b)
+	b.Route("/r", func(mux *nchi.Mux) {
+		mux.Mount("/a", a)
+	})
+
+	for name, tc := range map[string]struct {
+		mux  *nchi.Mux
+		path string
+	}{
+		"self":  {mux: self, path: "/self"},
+		"a b a": {mux: a, path: "/b/r/a"},
+	} {
+		assert.Len(t, tc.mux.Routes(), 1, name)
+		err := tc.mux.Bind()
+		var bindError *nchi.BindError
+		if assert.ErrorAs(t, err, &bindError, name) {
+			assert.Equal(t, "Mount", bindError.Special, name)
+			assert.Equal(t, tc.path, bindError.Path, name)
+			assert.Regexp(t, `mount_test.go:\d+$`, bindError.RegisteredAt, name)
+		}
+		w := httptest.NewRecorder()
+		tc.mux.ServeHTTP(w, httptest.NewRequest("GET", "/x", nil))
+		assert.Equal(t, 500, w.Code, name)
+	}
+}
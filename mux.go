@@ -2,6 +2,8 @@ package nchi
 
 import (
 	"net/http"
+	"sync"
+	"sync/atomic"
 
 	"github.com/muir/nject/v2"
 	"github.com/pkg/errors"
@@ -20,21 +22,82 @@ type Params = httprouter.Params
 // http://example.com/thing/3802, then the nchi.Endpoint will be "/thing/:thingID".
 type Endpoint string
 
+// ErrAlreadyBound is the panic value (wrapped) when routes or middleware
+// are added to a Mux that has already been bound.
+var ErrAlreadyBound = errors.New("nchi: Mux has already been bound")
+
 type Mux struct {
-	providers *nject.Collection // partial set
-	routes    []*Mux
-	path      string             // a fragment
-	method    string             // set for endpoints only
-	router    *httprouter.Router // only set at top-most
-	group     bool
-	options   []Option
-	special   *special
+	providers    *nject.Collection // partial set
+	inherited    *nject.Collection // the providers of the parent, set by add
+	own          *nject.Collection // the providers given for this Mux, set by add
+	routes       []*Mux
+	path         string    // a fragment
+	method       string    // set for endpoints only
+	name         RouteName // set for named endpoints only
+	matchers     []Matcher // set for endpoints with Matchers only
+	host         string    // set for Host only
+	version      string    // set for Version only
+	withoutErr   error     // set for Without only
+	subtree      bool      // set by Handle for paths that end with a slash
+	stacks       []*Stack  // Stacks used by this Mux and the Muxes it inherits from
+	group        bool
+	kind         string       // "Route", "Group", "With", "Without", "Mount", "Host", or "Version"
+	mounted      *Mux         // set for Mount only
+	handler      http.Handler // set for MountHandler only
+	files        *fileServer  // set for ServeFS and ServeSPA only
+	registeredAt string       // file:line of the call that created this Mux
+	options      []Option
+	special      *special
+	shared       *shared
+
+	// the following are only used at the top-most
+	bindLock sync.Mutex
+	bindDone bool
+	bindErr  error
+	bound    atomic.Pointer[bound]
+}
+
+// shared is common to all the Muxes that were created
+// from the same NewRouter
+type shared struct {
+	frozen atomic.Bool
+}
+
+// checkFrozen panics if the tree has been bound
+func (mux *Mux) checkFrozen(what string) {
+	if mux.shared.frozen.Load() {
+		panic(errors.Wrapf(ErrAlreadyBound, "%s at %s", what, callerSite()))
+	}
+}
+
+// freeze marks this tree and all mounted trees as bound
+func (mux *Mux) freeze() {
+	mux.freezeMounted(map[*Mux]bool{mux: true})
+}
+
+func (mux *Mux) freezeMounted(frozen map[*Mux]bool) {
+	mux.shared.frozen.Store(true)
+	for _, route := range mux.routes {
+		switch {
+		case route.mounted == nil:
+			route.freezeMounted(frozen)
+		case !frozen[route.mounted]:
+			frozen[route.mounted] = true
+			route.mounted.freezeMounted(frozen)
+		}
+	}
 }
 
 func (mux *Mux) add(n *Mux) *Mux {
+	mux.checkFrozen("cannot add route")
+	n.registeredAt = callerSite()
+	n.shared = mux.shared
 	mux.routes = append(mux.routes, n)
+	n.own = n.providers
 	if !n.group {
+		n.inherited = mux.providers
 		n.providers = mux.providers.Append(n.path, n.providers)
+		n.stacks = append(mux.stacks[:len(mux.stacks):len(mux.stacks)], n.stacks...)
 	}
 	return n
 }
@@ -44,90 +107,388 @@ func (mux *Mux) add(n *Mux) *Mux {
 func (mux *Mux) With(providers ...interface{}) *Mux {
 	return mux.add(&Mux{
 		providers: nject.Sequence(mux.path, translateMiddleware(providers)...),
+		stacks:    stacksIn(providers),
+		kind:      "With",
 	})
 }
 
 // Route establishes a new Mux at a new path (combined with the
 // current path context).
-func (mux *Mux) Route(path string, f func(mux *Mux)) {
+//
+// Options, if any, are combined with the options of the enclosing
+// Muxes and apply to requests for paths that start with the
+// combined path.  This allows, for example, different trailing slash
+// handling for different parts of the path space.
+func (mux *Mux) Route(path string, f func(mux *Mux), options ...Option) {
 	f(mux.add(&Mux{
 		path:      path,
 		providers: nject.Sequence(mux.path),
+		kind:      "Route",
+		options:   options,
 	}))
 }
 
 // Group establishes a new Mux at the current path but does
 // not inherit any middlewhere.
-func (mux *Mux) Group(f func(mux *Mux)) {
+//
+// Options, if any, are handled as they are for Route except that, since
+// a Group does not add to the path, they only apply to requests for the
+// paths of the routes established in the Group (or those paths with a
+// trailing slash added or removed).
+func (mux *Mux) Group(f func(mux *Mux), options ...Option) {
 	f(mux.add(&Mux{
 		group:     true,
 		providers: nject.Sequence(mux.path),
+		kind:      "Group",
+		options:   options,
 	}))
 }
 
 // Method registers an endpoint handler at the new path (combined with
 // the current path) using a combination of inherited middleware and
 // the providers here.
+//
+// Path variables are written in the httprouter style, ":name" and
+// "*name", or in the chi and net/http style, "{name}" and "{name...}".
+// A chi-style regular expression, "{name:[0-9]+}", becomes a constraint
+// (see WithConstraint).  The path is reported as written in Endpoint
+// and by Walk.  The same path syntax can be used with Route.
+//
+// The method can be any valid HTTP method, including custom methods
+// such as the WebDAV PROPFIND.  Custom methods are included in the
+// Allow header and in MethodNotAllowed handling just like the standard
+// methods.
+//
+// If one of the providers is a RouteName, then it is not used as a
+// provider and instead names the route so that URL can be used to
+// generate paths for it.  Likewise, providers that are Matchers add
+// requirements for the route instead of being injected.
 func (mux *Mux) Method(method string, path string, providers ...interface{}) {
-	mux.add(&Mux{
-		providers: nject.Sequence(method+" "+path, translateMiddleware(providers)...),
+	mux.addMethod(method, path, providers)
+}
+
+func (mux *Mux) addMethod(method string, path string, providers []interface{}) *Mux {
+	var name RouteName
+	var matchers []Matcher
+	n := make([]interface{}, 0, len(providers))
+	for _, p := range providers {
+		switch p := p.(type) {
+		case RouteName:
+			name = p
+		case Matcher:
+			matchers = append(matchers, p)
+		default:
+			n = append(n, p)
+		}
+	}
+	return mux.add(&Mux{
+		providers: nject.Sequence(method+" "+path, translateMiddleware(n)...),
+		stacks:    stacksIn(n),
 		method:    method,
 		path:      path,
+		name:      name,
+		matchers:  matchers,
 	})
 }
 
+// ServeHTTP binds the Mux if it has not already been bound.  If
+// binding fails, every request gets a 500 Internal Server Error response.
+// Call Bind before serving to find out why.
 func (mux *Mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	if mux.router == nil {
-		err := mux.Bind()
-		if err != nil {
-			panic(err.Error())
+	b := mux.bound.Load()
+	if b == nil {
+		if mux.Bind() != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
 		}
+		b = mux.bound.Load()
 	}
-	mux.router.ServeHTTP(w, r)
+	b.ServeHTTP(w, r)
 }
 
 // Bind validates that the injection chains for all routes are valid.
-// If any are not, an error is returned.  If you do not call bind, and
-// there are any invalid injection chains, then routes will panic when
-// used.
+// If any are not, an error is returned.  The error is a BindErrors
+// that lists every route that could not be bound.
+//
+// Routes that cannot be registered with httprouter, for example because
+// they conflict with another route, are also reported as errors.  The
+// error names both conflicting routes and where they were registered.
+//
+// Binding only happens once.  If you do not call Bind, it will be
+// called by the first ServeHTTP.  Additional calls to Bind return the
+// result of the first call.  Once bound, adding routes or middleware to
+// the Mux, or to any Mux mounted in it, panics with ErrAlreadyBound.
 func (mux *Mux) Bind() error {
-	router := httprouter.New()
-	for _, opt := range mux.options {
-		opt(&rtr{router})
+	mux.bindLock.Lock()
+	defer mux.bindLock.Unlock()
+	if mux.bindDone {
+		return mux.bindErr
 	}
-	err := mux.bind(router, "")
+	mux.bindDone = true
+	mux.freeze()
+	b, err := mux.bind()
 	if err != nil {
+		mux.bindErr = err
 		return err
 	}
-	mux.router = router
+	mux.bound.Store(b)
 	return nil
 }
 
-func (mux *Mux) bind(router *httprouter.Router, path string) error {
-	combinedPath := path + mux.path
-	for _, route := range mux.routes {
-		err := route.bind(router, combinedPath)
+// Replace binds next and then atomically switches mux to serve
+// the routes of next instead of its own routes (or the routes from a
+// previous Replace).  Requests that are already in progress finish with
+// the routes they started with.  If next cannot be bound, the error from
+// binding it is returned and mux continues to serve its current routes.
+//
+// This allows the routes to be rebuilt at runtime:
+//
+//	next := nchi.NewRouter()
+//	buildRoutes(next, newConfig)
+//	err := mux.Replace(next)
+//
+// Options, Walk, Routes, and URL on mux continue to refer to the routes that
+// were added to mux itself.  Use them on next to examine the replacement
+// routes.
+func (mux *Mux) Replace(next *Mux) error {
+	if next == mux {
+		return errors.New("nchi: a Mux cannot replace itself")
+	}
+	err := next.Bind()
+	if err != nil {
+		return err
+	}
+	mux.bindLock.Lock()
+	defer mux.bindLock.Unlock()
+	if !mux.bindDone {
+		mux.bindDone = true
+		mux.freeze()
+	}
+	mux.bindErr = nil
+	mux.bound.Store(next.bound.Load())
+	return nil
+}
+
+func (mux *Mux) bind() (*bound, error) {
+	b := &bound{
+		main: newTable(mux.options),
+	}
+	errs := mux.mountCycles("", []*Mux{mux})
+	if len(errs) != 0 {
+		return nil, errs
+	}
+	mux.findVersions(b)
+	mux.findMatched(b)
+	err := mux.walkAll(func(l *leaf) error {
+		err := l.bind(b.tableFor(l.host, mux.options))
 		if err != nil {
-			return err
+			errs = append(errs, l.bindError(err))
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	providers := nject.Sequence(path,
-		Endpoint(combinedPath),
-		mux.providers,
+	if len(errs) != 0 {
+		return nil, errs
+	}
+	err = b.finish()
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// leaf is an endpoint or special handler found by walking
+// the tree of Muxes
+type leaf struct {
+	mux          *Mux
+	path         string // the path of the parent
+	combinedPath string
+	outer        *nject.Collection
+	via          []string
+	host         string
+	options      []Option    // all options in effect
+	scopes       []leafScope // enclosing scopes, outermost first
+	panicHandler *Mux        // the nearest PanicHandler
+	version      string      // from Version
+	versioned    bool        // true if Version is used anywhere
+	outerStacks  []*Stack    // Stacks used by Muxes that did Mount
+	err          error       // from an enclosing Mux, reported by bind
+	mounts       []*Mux      // the top-level Mux and the Muxes mounted in it, outermost first
+}
+
+// leafScope is a Mux that has options or NotFound or MethodNotAllowed
+// handlers that apply to its subtree.
+type leafScope struct {
+	mux     *Mux
+	path    string
+	options []Option
+}
+
+// providers returns the combined set of providers for the leaf
+func (l *leaf) providers() *nject.Collection {
+	if l.panicHandler != nil && (l.mux.method != "" || l.mux.handler != nil) {
+		// errors are reported when binding the PanicHandler
+		ph, err := l.panicHandler.special.panicWrapper(l.panicHandler.own)
+		if err == nil {
+			return nject.Sequence(l.path,
+				Endpoint(l.combinedPath),
+				l.hostParams(),
+				l.originalMethod(),
+				l.apiVersion(),
+				l.mediaType(),
+				l.outer,
+				l.mux.inherited,
+				ph,
+				l.mux.own,
+			)
+		}
+	}
+	return nject.Sequence(l.path,
+		Endpoint(l.combinedPath),
+		l.hostParams(),
+		l.originalMethod(),
+		l.apiVersion(),
+		l.mediaType(),
+		l.outer,
+		l.mux.providers,
 	)
-	if mux.special != nil {
-		return mux.bindSpecial(router, combinedPath, providers)
+}
+
+func (mux *Mux) walkAll(f func(*leaf) error) error {
+	return mux.walk(leaf{
+		outer:     nject.Sequence("router"),
+		options:   mux.options,
+		versioned: mux.hasVersions(make(map[*Mux]bool)),
+		mounts:    []*Mux{mux},
+	}, f)
+}
+
+// walk visits all the endpoints and special handlers.  The outer
+// providers are only non-empty for Muxes that have been attached with
+// Mount: they're the providers of the Mux that did the mounting.
+func (mux *Mux) walk(parent leaf, f func(*leaf) error) error {
+	l := parent
+	l.mux = mux
+	l.path = parent.combinedPath
+	l.combinedPath = parent.combinedPath + mux.path
+	if mux.kind != "" {
+		l.via = append(parent.via[:len(parent.via):len(parent.via)], mux.kind)
+	}
+	if len(mux.options) != 0 && mux.kind != "" {
+		l.options = append(parent.options[:len(parent.options):len(parent.options)], mux.options...)
+	}
+	if mux.isScope() {
+		l.scopes = append(parent.scopes[:len(parent.scopes):len(parent.scopes)], leafScope{
+			mux:     mux,
+			path:    l.combinedPath,
+			options: l.options,
+		})
+	}
+	if mux.host != "" {
+		if parent.host != "" {
+			l.setErr(errors.Errorf("Host %s cannot be used inside Host %s", mux.host, parent.host))
+		} else {
+			l.host = mux.host
+		}
 	}
-	if mux.method == "" {
+	if mux.withoutErr != nil {
+		l.setErr(errors.Wrapf(mux.withoutErr, "Without at %s", mux.registeredAt))
+	}
+	if mux.version != "" {
+		if parent.version != "" {
+			l.setErr(errors.Errorf("Version %s cannot be used inside Version %s", mux.version, parent.version))
+		} else {
+			l.version = mux.version
+		}
+	}
+	if ph := mux.findPanicHandler(); ph != nil {
+		l.panicHandler = ph
+	}
+	if mux.mounted != nil {
+		if containsMux(l.mounts, mux.mounted) {
+			// reported by mountCycles
+			return nil
+		}
+		l.mounts = append(parent.mounts[:len(parent.mounts):len(parent.mounts)], mux.mounted)
+		l.outer = parent.outer.Append(l.combinedPath, mux.providers)
+		l.outerStacks = append(parent.outerStacks[:len(parent.outerStacks):len(parent.outerStacks)], mux.stacks...)
+		return mux.mounted.walk(l, f)
+	}
+	for _, route := range mux.routes {
+		err := route.walk(l, f)
+		if err != nil {
+			return err
+		}
+	}
+	if mux.method == "" && mux.special == nil && mux.handler == nil {
 		return nil
 	}
+	return f(&l)
+}
+
+// inScope reports if a Mux is one of the scopes of the leaf
+func (l *leaf) inScope(mux *Mux) bool {
+	for _, ls := range l.scopes {
+		if ls.mux == mux {
+			return true
+		}
+	}
+	return false
+}
+
+// setErr records a problem with an enclosing Mux.  Only the
+// outermost problem is kept.
+func (l *leaf) setErr(err error) {
+	if l.err == nil {
+		l.err = err
+	}
+}
+
+func (l *leaf) bind(router *table) error {
+	if l.err != nil {
+		return l.err
+	}
+	constraints := constraintsFor(l.options)
+	path, pcs, err := l.routerPattern(constraints)
+	if err != nil {
+		return err
+	}
+	err = l.checkStacks()
+	if err != nil {
+		return err
+	}
+	err = l.checkMatchers()
+	if err != nil {
+		return err
+	}
+	scoped := router.addScope(l, constraints)
+	switch {
+	case l.mux.handler != nil:
+		return l.bindHandler(router, path, pcs, l.providers())
+	case l.mux.files != nil:
+		return l.bindFiles(router, path, pcs, l.providers())
+	case l.mux.special != nil:
+		return l.bindSpecial(router, scoped, path, l.providers())
+	}
+	if l.mux.method != anyMethod && !validMethod(l.mux.method) {
+		return errors.Errorf("invalid method %q", l.mux.method)
+	}
 	var handle httprouter.Handle
-	err := providers.Bind(&handle, nil)
+	err = l.providers().Bind(&handle, nil)
 	if err != nil {
-		return errors.Wrapf(err, "bind router %s %s", mux.method, combinedPath)
+		return err
 	}
-	router.Handle(mux.method, combinedPath, handle)
-	return nil
+	handle = constrain(handle, pcs, router)
+	if l.mux.method == anyMethod {
+		router.addAny(l, path, router.matchOnly(l, handle))
+		return nil
+	}
+	if _, ok := router.matched[matchKey(l.mux.method, path)]; ok {
+		return router.handleMatched(l, l.mux.method, path, handle)
+	}
+	return router.handle(l, l.mux.method, path, handle)
 }
 
 // Use adds additional http middleware (implementing the http.Handler interface)
@@ -135,11 +496,13 @@ func (mux *Mux) bind(router *httprouter.Router, path string) error {
 // and providers will be injected into the handler chain for any downstream
 // endpoints that are defined after the call to Use.
 func (mux *Mux) Use(providers ...interface{}) {
+	mux.checkFrozen("cannot add middleware")
 	n := "router"
 	if mux.path != "" {
 		n = mux.path
 	}
 	mux.providers = mux.providers.Append(n, translateMiddleware(providers)...)
+	mux.stacks = append(mux.stacks, stacksIn(providers)...)
 }
 
 // Get establish a route for HTTP GET requests
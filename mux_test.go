@@ -1,14 +1,17 @@
 package nchi_test
 
 import (
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
 
 	"github.com/muir/nchi"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func makeDown(s string) func(string) string {
@@ -150,3 +153,120 @@ func TestEndpoint(t *testing.T) {
 	t.Log("->", got)
 	assert.Equal(t, "/thing/:thingID", got)
 }
+
+func TestLazyBindConcurrent(t *testing.T) {
+	mux := nchi.NewRouter()
+	mux.Use("")
+	mux.Get("/x", makeDown("a"), bottom)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest("GET", "/x", nil))
+			assert.Equal(t, "a", w.Body.String())
+		}()
+	}
+	wg.Wait()
+	assert.NoError(t, mux.Bind())
+}
+
+func TestBindFailure(t *testing.T) {
+	mux := nchi.NewRouter()
+	mux.Get("/x", bottom)
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", "/x", nil))
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	}
+	err := mux.Bind()
+	require.Error(t, err)
+	assert.Equal(t, err, mux.Bind(), "same error every time")
+}
+
+func TestAddAfterBind(t *testing.T) {
+	sub := nchi.NewRouter()
+	mux := nchi.NewRouter()
+	mux.Use("")
+	mux.Mount("/sub", sub)
+	require.NoError(t, mux.Bind())
+
+	for name, f := range map[string]func(){
+		"Get":   func() { mux.Get("/y", bottom) },
+		"Use":   func() { mux.Use(makeDown("a")) },
+		"Route": func() { mux.Route("/r", func(*nchi.Mux) {}) },
+		"sub":   func() { sub.Get("/y", bottom) },
+	} {
+		func() {
+			defer func() {
+				r := recover()
+				err, ok := r.(error)
+				if assert.True(t, ok, name) {
+					assert.True(t, errors.Is(err, nchi.ErrAlreadyBound), name)
+					assert.Contains(t, err.Error(), "mux_test.go:", name)
+				}
+			}()
+			f()
+		}()
+	}
+}
+
+func TestReplace(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	mux := nchi.NewRouter()
+	mux.Use("")
+	mux.Get("/x", makeDown("old"), func(s string, w http.ResponseWriter) {
+		close(started)
+		<-release
+		bottom(s, w)
+	})
+	require.NoError(t, mux.Bind())
+
+	inFlight := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		mux.ServeHTTP(inFlight, httptest.NewRequest("GET", "/x", nil))
+	}()
+	<-started
+
+	next := nchi.NewRouter()
+	next.Use("")
+	next.Get("/x", makeDown("new"), bottom)
+	require.NoError(t, mux.Replace(next))
+
+	doTest(t, mux, []testCase{
+		{path: "/x", want: "new"},
+	})
+	close(release)
+	<-done
+	assert.Equal(t, "old", inFlight.Body.String())
+
+	broken := nchi.NewRouter()
+	broken.Get("/x", bottom)
+	assert.Error(t, mux.Replace(broken))
+	assert.NoError(t, mux.Bind())
+	doTest(t, mux, []testCase{
+		{path: "/x", want: "new"},
+	})
+
+	assert.Error(t, mux.Replace(mux))
+}
+
+func TestReplaceUnbound(t *testing.T) {
+	mux := nchi.NewRouter()
+	mux.Get("/x", bottom)
+
+	next := nchi.NewRouter()
+	next.Use("")
+	next.Get("/x", makeDown("next"), bottom)
+	require.NoError(t, mux.Replace(next))
+	assert.NoError(t, mux.Bind())
+	doTest(t, mux, []testCase{
+		{path: "/x", want: "next"},
+	})
+}
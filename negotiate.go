@@ -0,0 +1,181 @@
+package nchi
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/muir/nject/v2"
+	"github.com/pkg/errors"
+)
+
+// MediaType is a type that handlers can accept as an input when their
+// route has a Produces Matcher.  It is the media type that was negotiated
+// with the Accept header of the request.
+type MediaType string
+
+type mediaTypeKey struct{}
+
+// Consumes returns a Matcher that requires that the Content-Type of a
+// request be one of the given media types.  Media types may have wildcards,
+// like "multipart/*".  Requests without a Content-Type do not match.
+// When no route matches because of Consumes, the response is 415
+// (Unsupported Media Type).  Bind fails if no media types are given.
+//
+//	mux.Post("/upload", nchi.Consumes("application/json"), uploadJSON)
+//	mux.Post("/upload", nchi.Consumes("multipart/form-data"), uploadForm)
+func Consumes(mediaTypes ...string) Matcher {
+	mediaTypes = lowerAll(mediaTypes)
+	return Matcher{
+		name:   "Consumes(" + strings.Join(mediaTypes, ", ") + ")",
+		status: http.StatusUnsupportedMediaType,
+		err:    noMediaTypes("Consumes", mediaTypes),
+		match: func(r *http.Request) bool {
+			contentType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if err != nil {
+				return false
+			}
+			for _, mediaType := range mediaTypes {
+				if mediaRangeMatches(mediaType, contentType) {
+					return true
+				}
+			}
+			return false
+		},
+	}
+}
+
+// Produces returns a Matcher that requires that the client accept one
+// of the given media types according to the Accept header of the request,
+// including q-values.  When several routes share a method and path, the
+// route with the media type that the client prefers is used.  When no
+// route is acceptable, the response is 406 (Not Acceptable).  Requests
+// without an Accept header accept everything.  Bind fails if no media
+// types are given.
+//
+// The negotiated media type can be injected as MediaType.
+//
+//	mux.Get("/report", nchi.Produces("application/json"), reportJSON)
+//	mux.Get("/report", nchi.Produces("text/csv"), reportCSV)
+func Produces(mediaTypes ...string) Matcher {
+	mediaTypes = lowerAll(mediaTypes)
+	return Matcher{
+		name:     "Produces(" + strings.Join(mediaTypes, ", ") + ")",
+		status:   http.StatusNotAcceptable,
+		produces: mediaTypes,
+		err:      noMediaTypes("Produces", mediaTypes),
+	}
+}
+
+func noMediaTypes(name string, mediaTypes []string) error {
+	if len(mediaTypes) == 0 {
+		return errors.Errorf("%s requires at least one media type", name)
+	}
+	return nil
+}
+
+func lowerAll(values []string) []string {
+	lowered := make([]string, len(values))
+	for i, value := range values {
+		lowered[i] = strings.ToLower(value)
+	}
+	return lowered
+}
+
+// negotiate picks the offered media type with the highest q-value.
+// Ties go to the earlier offer.
+func negotiate(accept []string, offers []string) (string, float64) {
+	if len(accept) == 0 {
+		return offers[0], 1
+	}
+	var ranges []acceptRange
+	for _, header := range accept {
+		for _, part := range strings.Split(header, ",") {
+			if ar, ok := parseAcceptRange(part); ok {
+				ranges = append(ranges, ar)
+			}
+		}
+	}
+	var best string
+	var bestQuality float64
+	for _, offer := range offers {
+		quality := acceptQuality(ranges, offer)
+		if quality > bestQuality {
+			best, bestQuality = offer, quality
+		}
+	}
+	return best, bestQuality
+}
+
+type acceptRange struct {
+	mediaRange string
+	quality    float64
+}
+
+func parseAcceptRange(part string) (acceptRange, bool) {
+	fields := strings.Split(part, ";")
+	ar := acceptRange{
+		mediaRange: strings.ToLower(strings.TrimSpace(fields[0])),
+		quality:    1,
+	}
+	if ar.mediaRange == "" {
+		return ar, false
+	}
+	for _, param := range fields[1:] {
+		name, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if !ok || strings.TrimSpace(name) != "q" {
+			continue
+		}
+		q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || q < 0 || q > 1 {
+			return ar, false
+		}
+		ar.quality = q
+	}
+	return ar, true
+}
+
+// acceptQuality is the q-value of the most specific media range
+// that matches the media type
+func acceptQuality(ranges []acceptRange, mediaType string) float64 {
+	specificity := -1
+	var quality float64
+	for _, ar := range ranges {
+		if !mediaRangeMatches(ar.mediaRange, mediaType) {
+			continue
+		}
+		s := 2 - strings.Count(ar.mediaRange, "*")
+		if s > specificity {
+			specificity = s
+			quality = ar.quality
+		}
+	}
+	return quality
+}
+
+// mediaRangeMatches reports if a media range, like "text/*", includes
+// a media type
+func mediaRangeMatches(mediaRange, mediaType string) bool {
+	if mediaRange == "*/*" || mediaRange == mediaType {
+		return true
+	}
+	if strings.HasSuffix(mediaRange, "/*") {
+		return strings.HasPrefix(mediaType, mediaRange[:len(mediaRange)-1])
+	}
+	return false
+}
+
+// mediaType returns a provider for MediaType.  Like hostParams, there
+// is no such provider unless the route has a Produces Matcher.
+func (l *leaf) mediaType() *nject.Collection {
+	for _, m := range l.mux.matchers {
+		if m.produces != nil {
+			return nject.Sequence("MediaType", func(r *http.Request) MediaType {
+				mediaType, _ := r.Context().Value(mediaTypeKey{}).(MediaType)
+				return mediaType
+			})
+		}
+	}
+	return nject.Sequence("MediaType")
+}
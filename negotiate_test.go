@@ -0,0 +1,87 @@
+package nchi_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/muir/nchi"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContentNegotiation(t *testing.T) {
+	show := func(s string) func(w http.ResponseWriter, m nchi.MediaType) {
+		return func(w http.ResponseWriter, m nchi.MediaType) {
+			_, _ = w.Write([]byte(s + " " + string(m)))
+		}
+	}
+	mux := nchi.NewRouter()
+	mux.Get("/report", nchi.Produces("application/json"), show("json"))
+	mux.Get("/report", nchi.Produces("text/csv", "text/plain"), show("text"))
+	mux.Post("/upload", nchi.Consumes("application/json"), func(w http.ResponseWriter) {
+		_, _ = w.Write([]byte("json upload"))
+	})
+	mux.Post("/upload", nchi.Consumes("multipart/*"), func(w http.ResponseWriter) {
+		_, _ = w.Write([]byte("multipart upload"))
+	})
+
+	cases := []struct {
+		name        string
+		method      string
+		path        string
+		accept      string
+		contentType string
+		status      int
+		want        string
+	}{
+		{name: "no accept", method: "GET", path: "/report", status: 200, want: "json application/json"},
+		{name: "csv", method: "GET", path: "/report", accept: "text/csv", status: 200, want: "text text/csv"},
+		{name: "q-values", method: "GET", path: "/report", accept: "application/json;q=0.5, text/*;q=0.8", status: 200, want: "text text/csv"},
+		{name: "specific beats wildcard", method: "GET", path: "/report", accept: "text/*;q=0.9, text/csv;q=0.1, text/plain", status: 200, want: "text text/plain"},
+		{name: "wildcard", method: "GET", path: "/report", accept: "*/*", status: 200, want: "json application/json"},
+		{name: "excluded", method: "GET", path: "/report", accept: "application/json;q=0, text/html", status: 406, want: "Not Acceptable\n"},
+		{name: "json upload", method: "POST", path: "/upload", contentType: "application/json; charset=utf-8", status: 200, want: "json upload"},
+		{name: "multipart upload", method: "POST", path: "/upload", contentType: "multipart/form-data; boundary=x", status: 200, want: "multipart upload"},
+		{name: "unsupported", method: "POST", path: "/upload", contentType: "text/xml", status: 415, want: "Unsupported Media Type\n"},
+		{name: "missing", method: "POST", path: "/upload", status: 415, want: "Unsupported Media Type\n"},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(tc.method, tc.path, strings.NewReader("{}"))
+			if tc.accept != "" {
+				r.Header.Set("Accept", tc.accept)
+			}
+			if tc.contentType != "" {
+				r.Header.Set("Content-Type", tc.contentType)
+			}
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, r)
+			assert.Equal(t, tc.status, w.Code)
+			assert.Equal(t, tc.want, w.Body.String())
+		})
+	}
+
+	mux = nchi.NewRouter()
+	mux.Get("/x", func(m nchi.MediaType) {})
+	assert.Error(t, mux.Bind(), "MediaType requires Produces")
+}
+
+func TestNegotiationErrors(t *testing.T) {
+	for name, matcher := range map[string]nchi.Matcher{
+		"Consumes": nchi.Consumes(),
+		"Produces": nchi.Produces(),
+	} {
+		mux := nchi.NewRouter()
+		mux.Get("/x", matcher, func() {})
+		mux.Get("/y", func() {})
+		err := mux.Bind()
+		var bindError *nchi.BindError
+		if assert.ErrorAs(t, err, &bindError, name) {
+			assert.Equal(t, "/x", bindError.Path, name)
+			assert.EqualError(t, bindError.Cause, name+" requires at least one media type")
+		}
+	}
+}
@@ -10,6 +10,22 @@ type Option func(*rtr)
 // rtr is defined the way it is so to prevent users from defining their own Options
 type rtr struct {
 	*httprouter.Router
+	constraints    map[string]func(string) bool
+	autoHEAD       bool
+	methodOverride map[string]bool
+	versioning     versioning
+}
+
+// applyOptions returns the settings for a set of options
+func applyOptions(options []Option) *rtr {
+	r := &rtr{
+		Router:      httprouter.New(),
+		constraints: make(map[string]func(string) bool),
+	}
+	for _, opt := range options {
+		opt(r)
+	}
+	return r
 }
 
 // The following comment is copied from https://github.com/julienschmidt/httprouter
@@ -67,9 +83,21 @@ func WithHandleOPTIONS(b bool) Option {
 	}
 }
 
+// WithAutoHEAD enables/disables answering HEAD requests for paths
+// that have a GET route but no HEAD route.  The injection chain of the
+// GET route is run with an http.ResponseWriter that discards the body
+// but still reports the Content-Length (unless the handler set it).
+// Routes registered with Head take precedence.  The default is: disabled.
+func WithAutoHEAD(b bool) Option {
+	return func(r *rtr) {
+		r.autoHEAD = b
+	}
+}
+
 func NewRouter(options ...Option) *Mux {
 	return &Mux{
 		providers: nject.Sequence("router"),
 		options:   options,
+		shared:    &shared{},
 	}
 }
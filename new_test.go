@@ -1,9 +1,13 @@
 package nchi_test
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/muir/nchi"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestWithRedirectTrailingSlash(t *testing.T) {
@@ -139,3 +143,127 @@ func TestWithHandleOPTIONS(t *testing.T) {
 		{path: "/ho8", want: "404 page not found\n"},
 	})
 }
+
+func TestSubtreeOptions(t *testing.T) {
+	mux := nchi.NewRouter()
+	mux.Use("")
+	mux.Use(makeDown("a"))
+	mux.Route("/api", func(mux *nchi.Mux) {
+		mux.Get("/ts", makeDown("b"), bottom)
+		mux.Post("/mna", makeDown("c"), bottom)
+		mux.Route("/web", func(mux *nchi.Mux) {
+			mux.Get("/ts", makeDown("d"), bottom)
+		}, nchi.WithRedirectTrailingSlash(true))
+	}, nchi.WithRedirectTrailingSlash(false), nchi.WithHandleMethodNotAllowed(false))
+	mux.Route("/web", func(mux *nchi.Mux) {
+		mux.Get("/ts", makeDown("e"), bottom)
+		mux.Post("/mna", makeDown("f"), bottom)
+	})
+
+	doTest(t, mux, []testCase{
+		{path: "/api/ts", want: "ab"},
+		{path: "/api/ts/", want: "404 page not found\n"},
+		{path: "/api/mna", want: "404 page not found\n"},
+		{path: "/api/web/ts/", want: "<a href=\"/api/web/ts\">Moved Permanently</a>.\n\n"},
+		{path: "/web/ts", want: "ae"},
+		{path: "/web/ts/", want: "<a href=\"/web/ts\">Moved Permanently</a>.\n\n"},
+		{path: "/web/mna", want: "Method Not Allowed\n"},
+	})
+}
+
+func TestGroupOptions(t *testing.T) {
+	mux := nchi.NewRouter()
+	mux.Use("")
+	mux.Route("/r", func(mux *nchi.Mux) {
+		mux.Get("/ts", makeDown("a"), bottom)
+		mux.Group(func(mux *nchi.Mux) {
+			mux.Use("")
+			mux.Get("/g", makeDown("b"), bottom)
+			mux.Get("/p/:id", makeDown("d"), bottom)
+		}, nchi.WithRedirectTrailingSlash(false))
+		mux.Get("/sib", makeDown("e"), bottom)
+	})
+	mux.Get("/ts", makeDown("c"), bottom)
+
+	doTest(t, mux, []testCase{
+		{path: "/r/g", want: "b"},
+		{path: "/r/g/", want: "404 page not found\n"},
+		{path: "/r/p/7/", want: "404 page not found\n"},
+		{path: "/r/ts/", want: "<a href=\"/r/ts\">Moved Permanently</a>.\n\n"},
+		{path: "/r/sib/", want: "<a href=\"/r/sib\">Moved Permanently</a>.\n\n"},
+		{path: "/ts/", want: "<a href=\"/ts\">Moved Permanently</a>.\n\n"},
+	})
+}
+
+func TestWithAutoHEAD(t *testing.T) {
+	mux := nchi.NewRouter(nchi.WithAutoHEAD(true))
+	mux.Use("")
+	mux.Get("/a", makeDown("hello"), bottom)
+	mux.Get("/b", makeDown("hello"), bottom)
+	mux.Head("/b", func(w http.ResponseWriter) {
+		w.Header().Set("X-Explicit", "yes")
+	})
+	mux.Get("/c", func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.Route("/off", func(mux *nchi.Mux) {
+		mux.Get("/d", makeDown("hello"), bottom)
+	}, nchi.WithAutoHEAD(false))
+
+	for _, tc := range []struct {
+		path     string
+		status   int
+		length   string
+		explicit string
+	}{
+		{path: "/a", status: 200, length: "5"},
+		{path: "/b", status: 200, explicit: "yes"},
+		{path: "/c", status: 204},
+		{path: "/off/d", status: 405},
+	} {
+		tc := tc
+		t.Run(tc.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest("HEAD", tc.path, nil))
+			assert.Equal(t, tc.status, w.Code)
+			assert.Equal(t, tc.length, w.Header().Get("Content-Length"))
+			assert.Equal(t, tc.explicit, w.Header().Get("X-Explicit"))
+			if tc.status < 300 {
+				assert.Empty(t, w.Body.String())
+			}
+		})
+	}
+
+	mux = nchi.NewRouter()
+	mux.Get("/a", func(w http.ResponseWriter) {})
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("HEAD", "/a", nil))
+	assert.Equal(t, 405, w.Code)
+}
+
+func TestWithAutoHEADFlush(t *testing.T) {
+	mux := nchi.NewRouter(nchi.WithAutoHEAD(true))
+	mux.Get("/flusher", func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("event one\n"))
+		w.(http.Flusher).Flush()
+		_, _ = w.Write([]byte("event two\n"))
+	})
+	mux.Get("/controller", func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusAccepted)
+		assert.NoError(t, http.NewResponseController(w).Flush())
+		_, _ = w.Write([]byte("event\n"))
+	})
+
+	for path, status := range map[string]int{
+		"/flusher":    200,
+		"/controller": 202,
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("HEAD", path, nil))
+		assert.Equal(t, status, w.Code, path)
+		assert.True(t, w.Flushed, path)
+		assert.Empty(t, w.Body.String(), path)
+		assert.Empty(t, w.Header().Get("Content-Length"), path)
+	}
+}
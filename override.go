@@ -0,0 +1,80 @@
+package nchi
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/muir/nject/v2"
+)
+
+// OriginalMethod is a type that handlers can accept as an input.  It
+// is the method of the request before any method override.  It can only
+// be injected into routes where WithMethodOverride is in effect.
+type OriginalMethod string
+
+type originalMethodKey struct{}
+
+// WithMethodOverride enables rewriting the method of POST requests
+// for clients that cannot send other methods.  The new method is taken
+// from the X-HTTP-Method-Override header or, if there is no such
+// header, from the "_method" field of a form.  Using the "_method" field
+// means that the request body is parsed with http.Request.ParseForm
+// when the Content-Type is application/x-www-form-urlencoded.
+//
+// Only the given methods may be the target of an override.  If no methods
+// are given, PUT, PATCH, and DELETE are allowed.  Override values are
+// not case sensitive.  Values that are not allowed are ignored and the
+// request is handled as a POST.
+//
+// The override happens before the route is looked up.  The original
+// method can be injected as OriginalMethod.
+//
+// Like other Options, WithMethodOverride can be given to Route or Group
+// in which case it only applies to requests for paths beneath.
+func WithMethodOverride(methods ...string) Option {
+	if len(methods) == 0 {
+		methods = []string{http.MethodPut, http.MethodPatch, http.MethodDelete}
+	}
+	allowed := make(map[string]bool)
+	for _, method := range methods {
+		allowed[strings.ToUpper(method)] = true
+	}
+	return func(r *rtr) {
+		r.methodOverride = allowed
+	}
+}
+
+// overrideMethod returns a request with the method replaced
+// if there is an allowed override
+func overrideMethod(allowed map[string]bool, r *http.Request) *http.Request {
+	if r.Method != http.MethodPost || len(allowed) == 0 {
+		return r
+	}
+	method := r.Header.Get("X-HTTP-Method-Override")
+	if method == "" && strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		method = r.PostFormValue("_method")
+	}
+	method = strings.ToUpper(strings.TrimSpace(method))
+	if !allowed[method] {
+		return r
+	}
+	r2 := r.WithContext(context.WithValue(r.Context(), originalMethodKey{}, OriginalMethod(r.Method)))
+	r2.Method = method
+	return r2
+}
+
+// originalMethod returns a provider for OriginalMethod.  Like
+// hostParams, there is no such provider where WithMethodOverride
+// is not in effect.
+func (l *leaf) originalMethod() *nject.Collection {
+	if len(applyOptions(l.options).methodOverride) == 0 {
+		return nject.Sequence("OriginalMethod")
+	}
+	return nject.Sequence("OriginalMethod", func(r *http.Request) OriginalMethod {
+		if original, ok := r.Context().Value(originalMethodKey{}).(OriginalMethod); ok {
+			return original
+		}
+		return OriginalMethod(r.Method)
+	})
+}
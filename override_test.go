@@ -0,0 +1,64 @@
+package nchi_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/muir/nchi"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithMethodOverride(t *testing.T) {
+	show := func(w http.ResponseWriter, r *http.Request, original nchi.OriginalMethod) {
+		_, _ = w.Write([]byte(string(original) + "->" + r.Method))
+	}
+	mux := nchi.NewRouter(nchi.WithMethodOverride())
+	mux.Post("/x", show)
+	mux.Put("/x", show)
+	mux.Delete("/x", show)
+	mux.Method("PURGE", "/x", show)
+	mux.Route("/strict", func(mux *nchi.Mux) {
+		mux.Post("/x", show)
+		mux.Patch("/x", show)
+		mux.Delete("/x", show)
+	}, nchi.WithMethodOverride("patch"))
+
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		header string
+		form   string
+		want   string
+	}{
+		{name: "none", method: "POST", path: "/x", want: "POST->POST"},
+		{name: "header", method: "POST", path: "/x", header: "put", want: "POST->PUT"},
+		{name: "form", method: "POST", path: "/x", form: "_method=DELETE&a=b", want: "POST->DELETE"},
+		{name: "not allowed", method: "POST", path: "/x", header: "PURGE", want: "POST->POST"},
+		{name: "not post", method: "PUT", path: "/x", header: "DELETE", want: "PUT->PUT"},
+		{name: "strict allowed", method: "POST", path: "/strict/x", header: "PATCH", want: "POST->PATCH"},
+		{name: "strict not allowed", method: "POST", path: "/strict/x", header: "DELETE", want: "POST->POST"},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.form))
+			if tc.header != "" {
+				r.Header.Set("X-HTTP-Method-Override", tc.header)
+			}
+			if tc.form != "" {
+				r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, r)
+			assert.Equal(t, tc.want, w.Body.String())
+		})
+	}
+
+	mux = nchi.NewRouter()
+	mux.Post("/x", show)
+	assert.Error(t, mux.Bind(), "OriginalMethod requires WithMethodOverride")
+}
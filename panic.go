@@ -0,0 +1,85 @@
+package nchi
+
+import (
+	"reflect"
+	"runtime/debug"
+
+	"github.com/muir/nject/v2"
+)
+
+// PanicStack is a type that panic handlers can accept as an input.  It
+// is the stack trace, as returned by debug.Stack, captured when a panic
+// was recovered.
+type PanicStack []byte
+
+var (
+	recoverInterfaceType = reflect.TypeOf((*RecoverInterface)(nil)).Elem()
+	panicStackType       = reflect.TypeOf(PanicStack(nil))
+	innerType            = reflect.TypeOf(func() {})
+)
+
+// findPanicHandler returns the first PanicHandler
+// registered directly on this Mux
+func (mux *Mux) findPanicHandler() *Mux {
+	routes := mux.routes
+	if mux.mounted != nil {
+		routes = mux.mounted.routes
+	}
+	for _, route := range routes {
+		if route.special != nil && route.special.panicHandler {
+			return route
+		}
+	}
+	return nil
+}
+
+// panicWrapper returns an nject wrapper that recovers panics and
+// invokes the panic handler chain.  The inputs of the panic handler chain,
+// other than RecoverInterface and PanicStack, become inputs of the wrapper
+// so that the panic handler can use any of the values that were injected
+// into the route.
+func (s *special) panicWrapper(handler *nject.Collection) (nject.Provider, error) {
+	s.panicOnce.Do(func() {
+		inputs, _ := handler.DownFlows()
+		invoke := reflect.New(reflect.FuncOf(inputs, nil, false))
+		err := handler.Bind(invoke.Interface(), nil)
+		if err != nil {
+			s.panicErr = err
+			return
+		}
+		wrapperInputs := []reflect.Type{innerType}
+		for _, t := range inputs {
+			if t != recoverInterfaceType && t != panicStackType {
+				wrapperInputs = append(wrapperInputs, t)
+			}
+		}
+		wrapper := reflect.MakeFunc(reflect.FuncOf(wrapperInputs, nil, false), func(args []reflect.Value) []reflect.Value {
+			defer func() {
+				r := recover()
+				if r == nil {
+					return
+				}
+				stack := PanicStack(debug.Stack())
+				values := make([]reflect.Value, len(inputs))
+				j := 1
+				for i, t := range inputs {
+					switch t {
+					case recoverInterfaceType:
+						values[i] = reflect.New(recoverInterfaceType).Elem()
+						values[i].Set(reflect.ValueOf(r))
+					case panicStackType:
+						values[i] = reflect.ValueOf(stack)
+					default:
+						values[i] = args[j]
+						j++
+					}
+				}
+				invoke.Elem().Call(values)
+			}()
+			args[0].Call(nil)
+			return nil
+		})
+		s.panicProvider = nject.Provide("PanicHandler", wrapper.Interface())
+	})
+	return s.panicProvider, s.panicErr
+}
@@ -0,0 +1,125 @@
+package nchi
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Handle establishes a route using a net/http ServeMux style pattern:
+// an optional method, followed by a space, followed by a path.
+//
+//	mux.Handle("GET /items/{id}", getItem)
+//	mux.Handle("/health", health)
+//
+// When there is no method, the route is established for all methods, as
+// with Any.  As with net/http, a path that ends with a slash matches all
+// paths that start with it unless it ends with {$}: "/static/" matches
+// like "/static/*rest" but is reported as written.  Such a route conflicts with other routes that
+// start with the same path, so use NotFound rather than "/" as a default.
+// Unlike net/http, a GET route does not also match HEAD requests (see
+// WithAutoHEAD).  Host names in patterns are not supported: use Host.
+func (mux *Mux) Handle(pattern string, providers ...interface{}) {
+	method, path := anyMethod, pattern
+	if i := strings.IndexAny(pattern, " \t"); i != -1 {
+		method = pattern[:i]
+		path = strings.TrimLeft(pattern[i:], " \t")
+	}
+	mux.addMethod(method, path, providers).subtree = strings.HasSuffix(path, "/")
+}
+
+// restParam is the name of the catch-all path variable for patterns
+// that do not name it
+const restParam = "rest"
+
+// routerPattern returns the httprouter pattern for a leaf and
+// the constraints on its path variables
+func (l *leaf) routerPattern(constraints map[string]func(string) bool) (string, []paramConstraint, error) {
+	pattern := l.combinedPath
+	if l.mux.subtree {
+		pattern += "*" + restParam
+	}
+	return routerPattern(pattern, constraints)
+}
+
+// routerPattern converts a pattern, as given to Method, Route, etc,
+// into the pattern that is given to httprouter and the constraints
+// on its path variables.
+func routerPattern(pattern string, constraints map[string]func(string) bool) (string, []paramConstraint, error) {
+	translated, err := translatePattern(pattern)
+	if err != nil {
+		return "", nil, err
+	}
+	return splitConstraints(translated, constraints)
+}
+
+// translatePattern converts chi-style and net/http style path variables
+// into httprouter-style path variables:
+//
+//	{id}            becomes :id
+//	{id:[0-9]+}     becomes :id{[0-9]+}
+//	{path...}       becomes *path
+//	{$}             is removed
+//	/* at the end   becomes /*rest
+//
+// Only braces that start a path segment are translated.  Braces that
+// follow a :param are constraints and are left alone.
+func translatePattern(pattern string) (string, error) {
+	if strings.HasSuffix(pattern, "/*") {
+		pattern += restParam
+	}
+	if !strings.Contains(pattern, "/{") {
+		return pattern, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		_ = b.WriteByte(c)
+		if c != '/' || i+1 >= len(pattern) || pattern[i+1] != '{' {
+			continue
+		}
+		start := i + 2
+		depth := 1
+		end := start
+		for ; end < len(pattern) && depth > 0; end++ {
+			switch pattern[end] {
+			case '{':
+				depth++
+			case '}':
+				depth--
+			}
+		}
+		if depth != 0 {
+			return "", errors.Errorf("unterminated { in %s", pattern)
+		}
+		// end is just past the closing brace
+		if end < len(pattern) && pattern[end] != '/' {
+			return "", errors.Errorf("path variable %s must be an entire path segment in %s", pattern[i+1:end], pattern)
+		}
+		inner := pattern[start : end-1]
+		switch {
+		case inner == "$":
+			if end != len(pattern) {
+				return "", errors.Errorf("{$} must be at the end of %s", pattern)
+			}
+		case strings.HasSuffix(inner, "..."):
+			if end != len(pattern) {
+				return "", errors.Errorf("%s must be at the end of %s", pattern[i+1:end], pattern)
+			}
+			_ = b.WriteByte('*')
+			_, _ = b.WriteString(strings.TrimSuffix(inner, "..."))
+		default:
+			_ = b.WriteByte(':')
+			if colon := strings.IndexByte(inner, ':'); colon != -1 {
+				_, _ = b.WriteString(inner[:colon])
+				_ = b.WriteByte('{')
+				_, _ = b.WriteString(inner[colon+1:])
+				_ = b.WriteByte('}')
+			} else {
+				_, _ = b.WriteString(inner)
+			}
+		}
+		i = end - 1
+	}
+	return b.String(), nil
+}
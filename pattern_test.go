@@ -0,0 +1,79 @@
+package nchi_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/muir/nchi"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPatterns(t *testing.T) {
+	endpoint := func(e nchi.Endpoint, params nchi.Params, w http.ResponseWriter) {
+		_, _ = w.Write([]byte(string(e)))
+		for _, p := range params {
+			_, _ = w.Write([]byte(" " + p.Key + "=" + p.Value))
+		}
+	}
+	mux := nchi.NewRouter()
+	mux.Get("/items/{id}", endpoint)
+	mux.Get("/num/{n:[0-9]+}", endpoint)
+	mux.Get("/typed/{n:int}/x", endpoint)
+	mux.Get("/files/{path...}", endpoint)
+	mux.Route("/users/{userID}", func(mux *nchi.Mux) {
+		mux.Get("/posts/{postID}", endpoint, nchi.RouteName("post"))
+	})
+	mux.Handle("POST /items/{id}", endpoint)
+	mux.Handle("/any/{$}", endpoint)
+	mux.Handle("GET /static/", endpoint)
+	mux.Get("/chi/*", endpoint)
+
+	doTest(t, mux, []testCase{
+		{path: "/items/7", want: "/items/{id} id=7"},
+		{path: "/num/42", want: "/num/{n:[0-9]+} n=42"},
+		{path: "/num/x", want: "404 page not found\n"},
+		{path: "/typed/3/x", want: "/typed/{n:int}/x n=3"},
+		{path: "/typed/y/x", want: "404 page not found\n"},
+		{path: "/files/a/b.txt", want: "/files/{path...} path=/a/b.txt"},
+		{path: "/users/u1/posts/p2", want: "/users/{userID}/posts/{postID} userID=u1 postID=p2"},
+		{path: "/any/", want: "/any/{$}"},
+		{path: "/any/x", want: "404 page not found\n"},
+		{path: "/static/", want: "/static/ rest=/"},
+		{path: "/static/css/a.css", want: "/static/ rest=/css/a.css"},
+		{path: "/chi/a/b", want: "/chi/* rest=/a/b"},
+	})
+	doTestMethod(t, mux, "POST", []testCase{
+		{path: "/items/8", want: "/items/{id} id=8"},
+	})
+	doTestMethod(t, mux, "DELETE", []testCase{
+		{path: "/any/", want: "/any/{$}"},
+	})
+
+	url, err := mux.URL("post", "userID", "u 1", "postID", "2")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "/users/u%201/posts/2", url)
+	}
+
+	var paths []string
+	for _, route := range mux.Routes() {
+		paths = append(paths, route.Method+" "+route.Path)
+	}
+	assert.Contains(t, paths, "GET /items/{id}")
+	assert.Contains(t, paths, "POST /items/{id}")
+	assert.Contains(t, paths, "* /any/{$}")
+	assert.Contains(t, paths, "GET /static/")
+}
+
+func TestPatternErrors(t *testing.T) {
+	for _, pattern := range []string{
+		"/a/{id}.json",
+		"/a/{path...}/b",
+		"/a/{id",
+		"/a/{$}/b",
+	} {
+		mux := nchi.NewRouter()
+		mux.Get(pattern, func() {})
+		assert.Error(t, mux.Bind(), pattern)
+	}
+}
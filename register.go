@@ -0,0 +1,119 @@
+package nchi
+
+import (
+	"fmt"
+	"io/fs"
+	"net/http"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+)
+
+var pkgPrefix = reflect.TypeOf((*Mux)(nil)).Elem().PkgPath() + "."
+
+// callerSite returns the file:line of the first caller
+// that is outside this package.
+func callerSite() string {
+	pc := make([]uintptr, 20)
+	n := runtime.Callers(2, pc)
+	frames := runtime.CallersFrames(pc[:n])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, pkgPrefix) {
+			return frame.File + ":" + strconv.Itoa(frame.Line)
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
+
+// registrar wraps an httprouter.Router, recording what has been
+// registered so that conflicts can be reported as errors rather
+// than panics.
+type registrar struct {
+	*httprouter.Router
+	registered []registration
+}
+
+type registration struct {
+	method string
+	path   string
+	leaf   *leaf
+	handle httprouter.Handle
+}
+
+func (reg registration) String() string {
+	return fmt.Sprintf("%s %s (registered at %s)", reg.method, reg.leaf.combinedPath, reg.leaf.mux.registeredAt)
+}
+
+// handle registers with the router.  If the router panics, it is
+// converted into an error that names the conflicting route, if any.
+func (r *registrar) handle(l *leaf, method, path string, handle httprouter.Handle) error {
+	reg := registration{
+		method: method,
+		path:   path,
+		leaf:   l,
+		handle: handle,
+	}
+	err := catchPanic(func() {
+		r.Router.Handle(method, path, handle)
+	})
+	if err != nil {
+		for _, prior := range r.registered {
+			if prior.method != method {
+				continue
+			}
+			if conflicts(prior.path, path) {
+				return errors.Errorf("conflicts with %s: %s", prior, err)
+			}
+		}
+		return err
+	}
+	r.registered = append(r.registered, reg)
+	return nil
+}
+
+// serveFiles is like httprouter's ServeFiles except that it
+// returns errors instead of panicing.
+func (r *registrar) serveFiles(l *leaf, path string, root http.FileSystem) error {
+	if len(path) < 10 || path[len(path)-10:] != "/*filepath" {
+		return errors.New("path must end with /*filepath")
+	}
+	handler := http.FileServer(root)
+	files := &fileServer{fsys: httpFS{root: root}}
+	return r.handle(l, "GET", path, func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		req.URL.Path = ps.ByName("filepath")
+		name := fsName(req.URL.Path)
+		if info, err := fs.Stat(files.fsys, name); err == nil && !info.IsDir() {
+			if etag := files.etag(name, info); etag != "" {
+				w.Header().Set("ETag", etag)
+			}
+		}
+		handler.ServeHTTP(w, req)
+	})
+}
+
+// conflicts reports if two paths cannot both be registered
+func conflicts(a, b string) bool {
+	router := httprouter.New()
+	nothing := func(http.ResponseWriter, *http.Request, httprouter.Params) {}
+	if catchPanic(func() { router.Handle("GET", a, nothing) }) != nil {
+		return false
+	}
+	return catchPanic(func() { router.Handle("GET", b, nothing) }) != nil
+}
+
+func catchPanic(f func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.New(fmt.Sprint(r))
+		}
+	}()
+	f()
+	return nil
+}
@@ -0,0 +1,107 @@
+package nchi_test
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/muir/nchi"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistrationConflicts(t *testing.T) {
+	cases := []struct {
+		name  string
+		setup func(mux *nchi.Mux)
+		want  string
+	}{
+		{
+			name: "duplicate",
+			setup: func(mux *nchi.Mux) {
+				mux.Get("/a", bottom)
+				mux.Get("/b", bottom)
+				mux.Route("/a", func(mux *nchi.Mux) {
+					mux.Get("", bottom)
+				})
+			},
+			want: `^bind GET /a \(registered at .*register_test.go:\d+\): conflicts with GET /a \(registered at .*register_test.go:\d+\): a handle is already registered for path '/a'$`,
+		},
+		{
+			name: "wildcard",
+			setup: func(mux *nchi.Mux) {
+				mux.Get("/x/:id", bottom)
+				mux.Post("/x/:name", bottom)
+				mux.Get("/x/:name", bottom)
+			},
+			want: `^bind GET /x/:name \(registered at .*register_test.go:\d+\): conflicts with GET /x/:id \(registered at .*register_test.go:\d+\): .*conflicts with existing wildcard.*$`,
+		},
+		{
+			name: "no conflicting route",
+			setup: func(mux *nchi.Mux) {
+				mux.Get("no/slash", bottom)
+			},
+			want: `^bind GET no/slash \(registered at .*register_test.go:\d+\): path must begin with '/' in path 'no/slash'$`,
+		},
+		{
+			name: "serve files",
+			setup: func(mux *nchi.Mux) {
+				mux.ServeFiles("/static", http.Dir("."))
+			},
+			want: `^bind ServeFiles GET /static \(registered at .*register_test.go:\d+\): path must end with /\*filepath$`,
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mux := nchi.NewRouter()
+			mux.Use("")
+			tc.setup(mux)
+			err := mux.Bind()
+			if assert.Error(t, err) {
+				assert.Regexp(t, tc.want, err.Error())
+			}
+		})
+	}
+}
+
+func TestBindErrors(t *testing.T) {
+	mux := nchi.NewRouter()
+	mux.Get("/a", func(s string) {})
+	mux.Get("/b", func(i int) {})
+	mux.Get("/c", func(w http.ResponseWriter) {})
+	mux.Get("/c", func(w http.ResponseWriter) {})
+	mux.NotFound(func(i int) {})
+	mux.Get("/ok", func(w http.ResponseWriter) {})
+
+	err := mux.Bind()
+	var bindErrors nchi.BindErrors
+	if !assert.True(t, errors.As(err, &bindErrors)) {
+		return
+	}
+	if assert.Len(t, bindErrors, 4) {
+		for i, want := range []struct {
+			method  string
+			path    string
+			special string
+		}{
+			{method: "GET", path: "/a"},
+			{method: "GET", path: "/b"},
+			{method: "GET", path: "/c"},
+			{path: "", special: "NotFound"},
+		} {
+			assert.Equal(t, want.method, bindErrors[i].Method, i)
+			assert.Equal(t, want.path, bindErrors[i].Path, i)
+			assert.Equal(t, want.special, bindErrors[i].Special, i)
+			assert.Contains(t, bindErrors[i].RegisteredAt, "register_test.go:", i)
+			assert.Error(t, bindErrors[i].Cause, i)
+		}
+	}
+	var bindError *nchi.BindError
+	if assert.True(t, errors.As(err, &bindError)) {
+		assert.Equal(t, "/a", bindError.Path)
+	}
+	assert.Contains(t, bindErrors[2].Cause.Error(), "conflicts with GET /c")
+	assert.Len(t, strings.Split(err.Error(), "\n"), 4)
+}
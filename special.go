@@ -2,8 +2,11 @@ package nchi
 
 import (
 	"net/http"
+	"runtime/debug"
+	"sync"
 
 	"github.com/julienschmidt/httprouter"
+
 	"github.com/muir/nject/v2"
 
 	"github.com/pkg/errors"
@@ -16,39 +19,99 @@ type special struct {
 	methodNotAllowed bool
 	notFound         bool
 	panicHandler     bool
+
+	// for panicHandler
+	panicOnce     sync.Once
+	panicProvider nject.Provider
+	panicErr      error
 }
 
-func bindSpecialHandler(name string, hf *http.Handler, combinedProviders *nject.Collection) error {
+func (s *special) name() string {
+	switch {
+	case s.serveFiles != nil:
+		return "ServeFiles"
+	case s.globalOPTIONS:
+		return "GlobalOPTIONS"
+	case s.methodNotAllowed:
+		return "MethodNotAllowed"
+	case s.notFound:
+		return "NotFound"
+	case s.panicHandler:
+		return "PanicHandler"
+	}
+	return ""
+}
+
+func bindSpecialHandler(hf *http.Handler, combinedProviders *nject.Collection) error {
 	if *hf == nil {
 		var handler http.HandlerFunc
 		err := combinedProviders.Bind(&handler, nil)
 		if err != nil {
-			return errors.Wrapf(err, "bind %s", name)
+			return err
 		}
 		*hf = handler
 	}
 	return nil
 }
 
-func (mux *Mux) bindSpecial(router *httprouter.Router, combinedPath string, combinedProviders *nject.Collection) error {
+// isScope is true for Muxes, other than the top-most, that have options or
+// that have NotFound, MethodNotAllowed, or PanicHandler handlers.  Scopes
+// that do not add to the path are limited to their own routes.
+func (mux *Mux) isScope() bool {
+	if mux.kind == "" {
+		return false
+	}
+	if len(mux.options) != 0 {
+		return true
+	}
+	routes := mux.routes
+	if mux.mounted != nil {
+		routes = mux.mounted.routes
+	}
+	for _, route := range routes {
+		if route.special != nil && (route.special.notFound || route.special.methodNotAllowed || route.special.panicHandler) {
+			return true
+		}
+	}
+	return false
+}
+
+// bindSpecial binds special handlers.  The scoped router is the router
+// for the innermost enclosing scope.
+func (l *leaf) bindSpecial(router *table, scoped *httprouter.Router, path string, combinedProviders *nject.Collection) error {
+	mux := l.mux
+	if mux.special.serveFiles == nil {
+		target := scoped
+		if mux.special.globalOPTIONS {
+			target = router.Router
+		}
+		err := router.registerSpecial(target, mux)
+		if err != nil {
+			return err
+		}
+	}
 	switch {
 	case mux.special.serveFiles != nil:
-		router.ServeFiles(combinedPath, mux.special.serveFiles)
+		return router.serveFiles(l, path, mux.special.serveFiles)
 	case mux.special.globalOPTIONS:
-		return bindSpecialHandler("GlobalOPTIONS", &router.GlobalOPTIONS, combinedProviders)
+		return bindSpecialHandler(&router.GlobalOPTIONS, combinedProviders)
 	case mux.special.methodNotAllowed:
-		return bindSpecialHandler("MethodNotAllowed", &router.MethodNotAllowed, combinedProviders)
+		return bindSpecialHandler(&scoped.MethodNotAllowed, combinedProviders)
 	case mux.special.notFound:
-		return bindSpecialHandler("NotFound", &router.NotFound, combinedProviders)
+		return bindSpecialHandler(&scoped.NotFound, combinedProviders)
 	case mux.special.panicHandler:
-		if router.PanicHandler == nil {
-			var ph func(w http.ResponseWriter, r *http.Request, rec RecoverInterface)
+		_, err := mux.special.panicWrapper(mux.own)
+		if err != nil {
+			return err
+		}
+		if scoped.PanicHandler == nil {
+			var ph func(w http.ResponseWriter, r *http.Request, rec RecoverInterface, stack PanicStack)
 			err := combinedProviders.Bind(&ph, nil)
 			if err != nil {
-				return errors.Wrap(err, "bind PanicHandler")
+				return err
 			}
-			router.PanicHandler = func(w http.ResponseWriter, r *http.Request, rec interface{}) {
-				ph(w, r, rec)
+			scoped.PanicHandler = func(w http.ResponseWriter, r *http.Request, rec interface{}) {
+				ph(w, r, rec, debug.Stack())
 			}
 		}
 	default:
@@ -57,9 +120,21 @@ func (mux *Mux) bindSpecial(router *httprouter.Router, combinedPath string, comb
 	return nil
 }
 
+// registerSpecial records a special handler so that a second registration
+// in the same scope is reported as an error rather than being ignored.
+func (t *table) registerSpecial(router *httprouter.Router, mux *Mux) error {
+	key := specialKey{router: router, name: mux.special.name()}
+	if previous, ok := t.specials[key]; ok {
+		return errors.Errorf("duplicate %s, first registered at %s", key.name, previous.registeredAt)
+	}
+	t.specials[key] = mux
+	return nil
+}
+
 func (mux *Mux) addSpecial(name string, providers []interface{}) *Mux {
 	return mux.add(&Mux{
 		providers: nject.Sequence(name, translateMiddleware(providers)...),
+		stacks:    stacksIn(providers),
 		special:   &special{},
 	})
 }
@@ -71,7 +146,8 @@ func (mux *Mux) addSpecial(name string, providers []interface{}) *Mux {
 // handler for the specific path was set.
 // The "Allowed" header is set before calling the handler.
 //
-// Only the first GobalOPTIONS call counts.
+// GlobalOPTIONS may only be called once per router (and once per Host):
+// a second call, anywhere in the tree, causes Bind to fail.
 func (mux *Mux) GlobalOPTIONS(providers ...interface{}) {
 	mux.addSpecial("globalOPTIONS", providers).special.globalOPTIONS = true
 }
@@ -82,7 +158,16 @@ func (mux *Mux) GlobalOPTIONS(providers ...interface{}) {
 // which is called when no matching route is
 // found. If it is not set, http.NotFound is used.
 //
-// Only the first NotFound call counts.
+// NotFound applies to the subtree where it is called.  When called
+// inside a Route, it handles requests for paths that start with the
+// path of the Route.  Requests that do not match any subtree with a NotFound
+// handler use the handler from the nearest enclosing Mux that has one.
+// When called inside a Group, which does not add to the path, it only
+// handles requests for the paths of the routes in the Group, for example
+// a file that ServeFS cannot find.
+//
+// NotFound may only be called once per Mux: a second call causes
+// Bind to fail.
 func (mux *Mux) NotFound(providers ...interface{}) {
 	mux.addSpecial("notFound", providers).special.notFound = true
 }
@@ -96,7 +181,11 @@ func (mux *Mux) NotFound(providers ...interface{}) {
 // The "Allow" header with allowed request methods is set before the handler
 // is called.
 //
-// Only the first MethodNotFound call counts.
+// Like NotFound, MethodNotAllowed applies to the subtree where it is called
+// and, inside a Group, to the paths of the routes in the Group.
+//
+// MethodNotAllowed may only be called once per Mux: a second call causes
+// Bind to fail.
 func (mux *Mux) MethodNotAllowed(providers ...interface{}) {
 	mux.addSpecial("methodNotAllowed", providers).special.methodNotAllowed = true
 }
@@ -113,11 +202,26 @@ type RecoverInterface interface{}
 // unrecovered panics.
 //
 // The type RecoverInterface can be used to receive the interface{} that
-// is returned from recover().
+// is returned from recover().  The type PanicStack can be used to receive
+// the stack trace of the panic.
+//
+// PanicHandler applies to the subtree where it is called.  Routes that
+// are not in a subtree with a PanicHandler use the handler from the nearest
+// enclosing Mux that has one.
+//
+// For each endpoint, the panic handler is injected into the endpoint's
+// injection chain after the middleware and before the endpoint's own
+// providers.  This means that the panic handler can receive any of the
+// values that are injected by middleware, such as Endpoint or a logger.
+// Panics in middleware are also caught, but for those the panic handler
+// is invoked with only the middleware that was in effect where PanicHandler
+// was called.  The same is true for endpoints whose injection chain cannot
+// provide what the panic handler needs.
 //
 // Alternatively, use the nvelope.CatchPanic middleware to catch panics.
 //
-// Only the first PanicHandler call counts.
+// PanicHandler may only be called once per Mux: a second call causes
+// Bind to fail.
 func (mux *Mux) PanicHandler(providers ...interface{}) {
 	mux.addSpecial("panicHandler", providers).special.panicHandler = true
 }
@@ -132,8 +236,10 @@ func (mux *Mux) PanicHandler(providers ...interface{}) {
 // the Router's NotFound handler. To use the operating system's file system
 // implementation, use http.Dir:
 //
-// Currently, ServeFiles does not use any middleware.  That may change in
-// a future release.
+// ServeFiles does not use any middleware.  Use ServeFS to serve files
+// through the middleware.  Like ServeFS, ServeFiles sets strong ETags
+// computed from the content of the files so that conditional requests
+// can be answered with 304 (Not Modified).
 func (mux *Mux) ServeFiles(path string, fs http.FileSystem) {
 	mux.add(&Mux{
 		path: path,
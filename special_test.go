@@ -3,9 +3,13 @@ package nchi_test
 import (
 	"fmt"
 	"net/http"
+	"strings"
 	"testing"
+	"testing/fstest"
 
 	"github.com/muir/nchi"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestGlobalOPTIONS(t *testing.T) {
@@ -67,3 +71,165 @@ func TestPanicHandler(t *testing.T) {
 		{path: "/ph2", want: "recover runtime.boundsError-a"},
 	})
 }
+
+func TestScopedNotFound(t *testing.T) {
+	mux := nchi.NewRouter()
+	mux.Use("")
+	mux.Use(makeDown("a"))
+	mux.NotFound(makeDown("n"), bottom)
+	mux.Route("/api", func(mux *nchi.Mux) {
+		mux.Use(makeDown("j"))
+		mux.NotFound(makeDown("-json404"), bottom)
+		mux.Post("/x/:id{int}", bottom)
+		mux.MethodNotAllowed(makeDown("-json405"), bottom)
+		mux.Route("/inner", func(mux *nchi.Mux) {
+			mux.Get("/y", bottom)
+		})
+	})
+	mux.Route("/web", func(mux *nchi.Mux) {
+		mux.Use(makeDown("h"))
+		mux.NotFound(makeDown("-html404"), bottom)
+		mux.Get("/page", bottom)
+	})
+	mux.Post("/top", bottom)
+
+	doTest(t, mux, []testCase{
+		{path: "/api/nope", want: "aj-json404"},
+		{path: "/api/inner/nope", want: "aj-json404"},
+		{path: "/api/x/7", want: "aj-json405"},
+		{path: "/web/nope", want: "ah-html404"},
+		{path: "/web/page", want: "ah"},
+		{path: "/nope", want: "an"},
+		{path: "/top", want: "Method Not Allowed\n"},
+	})
+	doTestMethod(t, mux, "POST", []testCase{
+		{path: "/api/x/abc", want: "aj-json404"},
+		{path: "/api/x/7", want: "aj"},
+	})
+}
+
+func TestGroupNotFound(t *testing.T) {
+	mux := nchi.NewRouter()
+	mux.Use("")
+	mux.Route("/r", func(mux *nchi.Mux) {
+		mux.NotFound(makeDown("A"), bottom)
+		mux.MethodNotAllowed(makeDown("M"), bottom)
+		mux.Get("/sib", bottom)
+		mux.Group(func(mux *nchi.Mux) {
+			mux.Use("")
+			mux.NotFound(makeDown("B"), bottom)
+			mux.MethodNotAllowed(makeDown("N"), bottom)
+			mux.Post("/g", bottom)
+			mux.ServeFS("/files/*filepath", fstest.MapFS{
+				"a.txt": {Data: []byte("file a")},
+			})
+		})
+	})
+
+	doTest(t, mux, []testCase{
+		{path: "/r/missing", want: "A"},
+		{path: "/r/sib/x", want: "A"},
+		{path: "/r/files/a.txt", want: "file a"},
+		{path: "/r/files/b.txt", want: "B"},
+		{path: "/r/g", want: "N"},
+	})
+	doTestMethod(t, mux, "POST", []testCase{
+		{path: "/r/sib", want: "M"},
+	})
+}
+
+func TestScopedPanicHandler(t *testing.T) {
+	boom := func() { panic("boom") }
+	mux := nchi.NewRouter()
+	mux.Use("")
+	mux.Use(makeDown("a"))
+	mux.PanicHandler(func(w http.ResponseWriter, s string, r nchi.RecoverInterface) {
+		_, _ = fmt.Fprintf(w, "%s-top-%v", s, r)
+	})
+	mux.Get("/p", makeDown("b"), boom)
+	mux.Route("/api", func(mux *nchi.Mux) {
+		mux.Use(makeDown("j"))
+		mux.PanicHandler(func(w http.ResponseWriter, s string, e nchi.Endpoint, r nchi.RecoverInterface, stack nchi.PanicStack) {
+			_, _ = fmt.Fprintf(w, "%s-api-%s-%v-%v", s, e, r, strings.Contains(string(stack), "TestScopedPanicHandler"))
+		})
+		mux.Get("/p/:id", makeDown("c"), boom)
+		mux.Group(func(mux *nchi.Mux) {
+			mux.Use(makeDown("g"))
+			mux.Get("/g", boom)
+		})
+	})
+
+	doTest(t, mux, []testCase{
+		{path: "/p", want: "a-top-boom"},
+		{path: "/api/p/7", want: "aj-api-/api/p/:id-boom-true"},
+		// groups do not inherit middleware so the string from "aj" is not
+		// available and the panic is handled with the PanicHandler's own chain
+		{path: "/api/g", want: "aj-api-/api-boom-true"},
+	})
+}
+
+func TestDuplicateSpecial(t *testing.T) {
+	cases := []struct {
+		name  string
+		setup func(mux *nchi.Mux)
+		want  string
+	}{
+		{
+			name: "NotFound",
+			setup: func(mux *nchi.Mux) {
+				mux.NotFound(bottom)
+				mux.NotFound(bottom)
+			},
+			want: `^bind NotFound  \(registered at .*special_test.go:\d+\): duplicate NotFound, first registered at .*special_test.go:\d+$`,
+		},
+		{
+			name: "MethodNotAllowed in Route",
+			setup: func(mux *nchi.Mux) {
+				mux.MethodNotAllowed(bottom)
+				mux.Route("/r", func(mux *nchi.Mux) {
+					mux.MethodNotAllowed(bottom)
+					mux.MethodNotAllowed(bottom)
+				})
+			},
+			want: `^bind MethodNotAllowed /r \(registered at .*\): duplicate MethodNotAllowed, first registered at .*special_test.go:\d+$`,
+		},
+		{
+			name: "PanicHandler",
+			setup: func(mux *nchi.Mux) {
+				mux.PanicHandler(func(w http.ResponseWriter) {})
+				mux.PanicHandler(func(w http.ResponseWriter) {})
+			},
+			want: `duplicate PanicHandler`,
+		},
+		{
+			name: "GlobalOPTIONS in Route",
+			setup: func(mux *nchi.Mux) {
+				mux.GlobalOPTIONS(bottom)
+				mux.Route("/r", func(mux *nchi.Mux) {
+					mux.GlobalOPTIONS(bottom)
+				})
+			},
+			want: `duplicate GlobalOPTIONS`,
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mux := nchi.NewRouter()
+			mux.Use("")
+			tc.setup(mux)
+			err := mux.Bind()
+			if assert.Error(t, err) {
+				assert.Regexp(t, tc.want, err.Error())
+			}
+		})
+	}
+
+	mux := nchi.NewRouter()
+	mux.Use("")
+	mux.NotFound(bottom)
+	mux.Route("/r", func(mux *nchi.Mux) {
+		mux.NotFound(bottom)
+	})
+	assert.NoError(t, mux.Bind())
+}
@@ -0,0 +1,153 @@
+package nchi
+
+import (
+	"github.com/muir/nject/v2"
+	"github.com/pkg/errors"
+)
+
+// Stack is a named and versioned bundle of middleware and providers
+// that can be used by many routers.  Pass a Stack to Use, With, or Method
+// like any other provider.
+//
+// Each layer of a Stack has a name.  A Stack can declare that one layer
+// must come before another.  Layers are recognized by the identity of
+// their providers so the names given to the providers themselves, for
+// example by nject.Provide, do not matter.  These ordering constraints
+// are checked when the Mux is bound: Bind fails if, in the injection chain
+// of any route, the layers are out of order or a layer of the Stack that
+// is named by a constraint is missing (see Without).  Constraints can also
+// name layers of other Stacks or any provider named with nject.Provide.
+// Those are only checked when they are present.
+//
+//	var API = nchi.NewStack("api", "1.0").
+//		Add("EncodeJSON", nvelope.EncodeJSON).
+//		Add("CatchPanic", nvelope.CatchPanic).
+//		Order("EncodeJSON", "CatchPanic")
+//
+// Stacks are immutable: Add and Order return new Stacks.
+type Stack struct {
+	name    string
+	version string
+	layers  *nject.Collection
+	layerOf map[nject.Provider]string
+	orders  []stackOrder
+	err     error // reported by Bind
+}
+
+type stackOrder struct {
+	first  string
+	second string
+}
+
+// NewStack creates an empty Stack
+func NewStack(name string, version string) *Stack {
+	return &Stack{
+		name:    name,
+		version: version,
+		layers:  nject.Sequence(name),
+	}
+}
+
+// Name returns the name of the Stack
+func (s *Stack) Name() string { return s.name }
+
+// Version returns the version of the Stack
+func (s *Stack) Version() string { return s.version }
+
+// String returns the name and version of the Stack
+func (s *Stack) String() string { return s.name + "@" + s.version }
+
+// Add returns a new Stack with an additional layer.  Standard middleware
+// is translated as it is for Use.
+func (s *Stack) Add(name string, providers ...interface{}) *Stack {
+	layer := nject.Sequence(name, translateMiddleware(providers)...)
+	n := *s
+	n.layers = s.layers.Append(name, layer)
+	n.layerOf = make(map[nject.Provider]string, len(s.layerOf)+len(providers))
+	for p, name := range s.layerOf {
+		n.layerOf[p] = name
+	}
+	layer.ForEachProvider(func(p nject.Provider) {
+		n.layerOf[p] = name
+	})
+	return &n
+}
+
+// Order returns a new Stack with the constraint that the layer
+// (or provider) named first must precede the one named second when
+// both are in an injection chain.  At least one of the two must be a
+// layer that has already been added to the Stack: if not, Bind fails
+// for every route that uses the Stack.
+func (s *Stack) Order(first string, second string) *Stack {
+	n := *s
+	if n.err == nil && !s.hasLayer(first) && !s.hasLayer(second) {
+		n.err = errors.Errorf("stack %s: Order(%s, %s) does not name a layer of the stack", s, first, second)
+	}
+	n.orders = append(s.orders[:len(s.orders):len(s.orders)], stackOrder{
+		first:  first,
+		second: second,
+	})
+	return &n
+}
+
+func (s *Stack) hasLayer(name string) bool {
+	for _, layer := range s.layerOf {
+		if layer == name {
+			return true
+		}
+	}
+	return false
+}
+
+// stacksIn returns the Stacks in a list of providers
+func stacksIn(providers []interface{}) []*Stack {
+	var stacks []*Stack
+	for _, p := range providers {
+		if s, ok := p.(*Stack); ok {
+			stacks = append(stacks, s)
+		}
+	}
+	return stacks
+}
+
+// checkStacks verifies the ordering constraints of the Stacks
+// used by a leaf
+func (l *leaf) checkStacks() error {
+	stacks := append(l.outerStacks[:len(l.outerStacks):len(l.outerStacks)], l.mux.stacks...)
+	if len(stacks) == 0 {
+		return nil
+	}
+	positions := make(map[string]int)
+	var i int
+	l.providers().ForEachProvider(func(p nject.Provider) {
+		name := providerName(p)
+		for _, s := range stacks {
+			if layer, ok := s.layerOf[p]; ok {
+				name = layer
+				break
+			}
+		}
+		if _, ok := positions[name]; !ok {
+			positions[name] = i
+		}
+		i++
+	})
+	for _, s := range stacks {
+		if s.err != nil {
+			return s.err
+		}
+		for _, o := range s.orders {
+			for _, name := range []string{o.first, o.second} {
+				if _, ok := positions[name]; !ok && s.hasLayer(name) {
+					return errors.Errorf("stack %s: layer %s is missing", s, name)
+				}
+			}
+			first, ok1 := positions[o.first]
+			second, ok2 := positions[o.second]
+			if ok1 && ok2 && first > second {
+				return errors.Errorf("stack %s: %s must precede %s", s, o.first, o.second)
+			}
+		}
+	}
+	return nil
+}
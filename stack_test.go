@@ -0,0 +1,123 @@
+package nchi_test
+
+import (
+	"testing"
+
+	"github.com/muir/nchi"
+	"github.com/muir/nject/v2"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStack(t *testing.T) {
+	stack := nchi.NewStack("common", "1.2").
+		Add("log", makeDown("log-")).
+		Add("auth", nject.Provide("authenticate", makeDown("auth-"))).
+		Order("log", "auth")
+	assert.Equal(t, "common", stack.Name())
+	assert.Equal(t, "1.2", stack.Version())
+	assert.Equal(t, "common@1.2", stack.String())
+
+	for _, prefix := range []string{"a", "b"} {
+		mux := nchi.NewRouter()
+		mux.Use("")
+		mux.Use(stack)
+		mux.Get("/x", bottom)
+		mux.With(makeDown(prefix)).Get("/y", bottom)
+		doTest(t, mux, []testCase{
+			{path: "/x", want: "log-auth-"},
+			{path: "/y", want: "log-auth-" + prefix},
+		})
+	}
+}
+
+func TestStackOrder(t *testing.T) {
+	backwards := nchi.NewStack("backwards", "1").
+		Add("auth", makeDown("auth-")).
+		Add("log", makeDown("log-")).
+		Order("log", "auth")
+	needsTrace := nchi.NewStack("needsTrace", "2").
+		Add("auth", makeDown("auth-")).
+		Order("trace", "auth")
+	trace := nject.Provide("trace", makeDown("trace-"))
+
+	cases := []struct {
+		name  string
+		setup func(mux *nchi.Mux)
+		want  string
+	}{
+		{
+			name: "within a stack",
+			setup: func(mux *nchi.Mux) {
+				mux.Get("/x", backwards, bottom)
+			},
+			want: "stack backwards@1: log must precede auth",
+		},
+		{
+			name: "named provider",
+			setup: func(mux *nchi.Mux) {
+				mux.Use(needsTrace)
+				mux.With(trace).Get("/x", bottom)
+			},
+			want: "stack needsTrace@2: trace must precede auth",
+		},
+		{
+			name: "mounted",
+			setup: func(mux *nchi.Mux) {
+				sub := nchi.NewRouter()
+				sub.Use(trace)
+				sub.Get("/x", bottom)
+				mux.Use(needsTrace)
+				mux.Mount("/sub", sub)
+			},
+			want: "stack needsTrace@2: trace must precede auth",
+		},
+		{
+			name: "typo",
+			setup: func(mux *nchi.Mux) {
+				mux.Use(nchi.NewStack("typo", "1").
+					Add("B", makeDown("b-")).
+					Order("B", "Typo").
+					Order("Typo", "Other"))
+				mux.Get("/x", bottom)
+			},
+			want: "stack typo@1: Order(Typo, Other) does not name a layer of the stack",
+		},
+		{
+			name: "layer removed",
+			setup: func(mux *nchi.Mux) {
+				mux.Use(needsTrace)
+				mux.Without("auth").With(trace).Get("/x", bottom)
+			},
+			want: "stack needsTrace@2: layer auth is missing",
+		},
+		{
+			name: "missing providers are not checked",
+			setup: func(mux *nchi.Mux) {
+				mux.Use(needsTrace)
+				mux.Get("/x", bottom)
+			},
+		},
+		{
+			name: "correct order",
+			setup: func(mux *nchi.Mux) {
+				mux.Use(trace, needsTrace)
+				mux.Get("/x", bottom)
+			},
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mux := nchi.NewRouter()
+			mux.Use("")
+			tc.setup(mux)
+			err := mux.Bind()
+			if tc.want == "" {
+				assert.NoError(t, err)
+			} else if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.want)
+			}
+		})
+	}
+}
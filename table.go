@@ -0,0 +1,352 @@
+package nchi
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// bound is the result of binding a Mux
+type bound struct {
+	main       *table
+	hosts      []hostTable
+	versions   []string
+	versioning versioning
+}
+
+type hostTable struct {
+	labels []string
+	*table
+}
+
+// table holds the routes for one host pattern (or for requests
+// that do not match any host pattern).  Every route is registered
+// with the registrar.  Subtrees that have their own options have
+// a router, a scope, of their own that has all the same routes but
+// different options.
+type table struct {
+	*registrar
+	options  []Option
+	scopes   []*scope
+	specials map[specialKey]*Mux
+	any      []registration // routes from Any, registered by finish
+	override map[string]bool
+	matched  map[string]*matchGroup // method and path to routes with Matchers
+	fallback *httprouter.Router     // routes for methods that are not in methods
+	methods  map[string]bool        // the methods of the registered routes
+}
+
+// specialKey identifies a special handler within a table: the
+// router that it is set on and the kind of handler.
+type specialKey struct {
+	router *httprouter.Router
+	name   string
+}
+
+type scope struct {
+	mux      *Mux
+	prefix   []string // the segments of the combined path
+	depth    int
+	parent   *scope // nil if the parent is the table
+	router   *httprouter.Router
+	override map[string]bool
+	members  *httprouter.Router // set if the scope does not add to the path
+	methods  []string           // the methods of members
+}
+
+func newRouter(options []Option) *httprouter.Router {
+	router := httprouter.New()
+	r := &rtr{
+		Router:      router,
+		constraints: make(map[string]func(string) bool),
+	}
+	for _, opt := range options {
+		opt(r)
+	}
+	return router
+}
+
+func newTable(options []Option) *table {
+	return &table{
+		registrar: &registrar{Router: newRouter(options)},
+		options:   options,
+		specials:  make(map[specialKey]*Mux),
+		override:  applyOptions(options).methodOverride,
+		matched:   make(map[string]*matchGroup),
+	}
+}
+
+func (b *bound) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if len(b.versions) != 0 {
+		var ok bool
+		r, ok = b.selectVersion(w, r)
+		if !ok {
+			return
+		}
+	}
+	b.tableForRequest(r).ServeHTTP(w, r)
+}
+
+// tableFor returns the table for a host pattern, creating
+// it if needed.
+func (b *bound) tableFor(host string, options []Option) *table {
+	if host == "" {
+		return b.main
+	}
+	labels := strings.Split(host, ".")
+	for _, ht := range b.hosts {
+		if strings.Join(ht.labels, ".") == host {
+			return ht.table
+		}
+	}
+	ht := hostTable{
+		labels: labels,
+		table:  newTable(options),
+	}
+	b.hosts = append(b.hosts, ht)
+	sort.SliceStable(b.hosts, func(i, j int) bool {
+		return b.hosts[i].vars() < b.hosts[j].vars()
+	})
+	return ht.table
+}
+
+func (ht hostTable) vars() int {
+	var count int
+	for _, label := range ht.labels {
+		if strings.HasPrefix(label, ":") {
+			count++
+		}
+	}
+	return count
+}
+
+func (b *bound) tableForRequest(r *http.Request) *table {
+	for _, ht := range b.hosts {
+		if matchHost(ht.labels, r.Host) != nil {
+			return ht.table
+		}
+	}
+	return b.main
+}
+
+// finish is called after all routes have been registered
+func (b *bound) finish() error {
+	var errs BindErrors
+	for _, ht := range b.hosts {
+		inheritSpecial(ht.Router, b.main.Router)
+		errs = append(errs, ht.finish()...)
+	}
+	errs = append(errs, b.main.finish()...)
+	if len(errs) != 0 {
+		return errs
+	}
+	return nil
+}
+
+// inheritSpecial copies special handlers that
+// are not set from another router
+func inheritSpecial(to *httprouter.Router, from *httprouter.Router) {
+	if to.GlobalOPTIONS == nil {
+		to.GlobalOPTIONS = from.GlobalOPTIONS
+	}
+	if to.NotFound == nil {
+		to.NotFound = from.NotFound
+	}
+	if to.MethodNotAllowed == nil {
+		to.MethodNotAllowed = from.MethodNotAllowed
+	}
+	if to.PanicHandler == nil {
+		to.PanicHandler = from.PanicHandler
+	}
+}
+
+// addScope notes the scopes of a leaf and returns the router
+// for the innermost scope.
+func (t *table) addScope(l *leaf, constraints map[string]func(string) bool) *httprouter.Router {
+	router := t.Router
+	var parent *scope
+Scopes:
+	for i, ls := range l.scopes {
+		for _, s := range t.scopes {
+			if s.mux == ls.mux {
+				parent = s
+				router = s.router
+				continue Scopes
+			}
+		}
+		prefix, _, err := routerPattern(ls.path, constraints)
+		if err != nil {
+			prefix = ls.path
+		}
+		s := &scope{
+			mux:      ls.mux,
+			prefix:   strings.Split(strings.TrimSuffix(prefix, "/"), "/"),
+			depth:    i,
+			parent:   parent,
+			router:   newRouter(ls.options),
+			override: applyOptions(ls.options).methodOverride,
+		}
+		if ls.mux.path == "" && ls.mux.kind != "Host" {
+			// Groups and the like share their path with the enclosing
+			// Mux so they are limited to their own routes
+			s.members = httprouter.New()
+		}
+		t.scopes = append(t.scopes, s)
+		parent = s
+		router = s.router
+	}
+	return router
+}
+
+// finish populates the scope routers.  The most specific
+// scopes are sorted first.
+func (t *table) finish() BindErrors {
+	errs := t.registerAny()
+	t.addAutoHEAD()
+	t.methods = make(map[string]bool)
+	for _, reg := range t.registered {
+		t.methods[reg.method] = true
+	}
+	// parent scopes are always created before their children
+	for _, s := range t.scopes {
+		for _, reg := range t.registered {
+			s.router.Handle(reg.method, reg.path, reg.handle)
+		}
+		from := t.Router
+		if s.parent != nil {
+			from = s.parent.router
+		}
+		inheritSpecial(s.router, from)
+		if s.members != nil {
+			s.addMembers(t.registered)
+		}
+	}
+	sort.SliceStable(t.scopes, func(i, j int) bool {
+		if len(t.scopes[i].prefix) != len(t.scopes[j].prefix) {
+			return len(t.scopes[i].prefix) > len(t.scopes[j].prefix)
+		}
+		return t.scopes[i].depth > t.scopes[j].depth
+	})
+	return errs
+}
+
+// routerFor returns the router that handles a path and the
+// method overrides that are allowed for the path
+func (t *table) routerFor(path string) (*httprouter.Router, map[string]bool) {
+	if len(t.scopes) == 0 {
+		return t.Router, t.override
+	}
+	segments := strings.Split(path, "/")
+	for _, s := range t.scopes {
+		if s.matches(path, segments) {
+			return s.router, s.override
+		}
+	}
+	return t.Router, t.override
+}
+
+// addMembers records the routes that are inside a scope
+// that does not add to the path
+func (s *scope) addMembers(registered []registration) {
+	methods := make(map[string]bool)
+	for _, reg := range registered {
+		if !reg.leaf.inScope(s.mux) {
+			continue
+		}
+		s.members.Handle(reg.method, reg.path, reg.handle)
+		if !methods[reg.method] {
+			methods[reg.method] = true
+			s.methods = append(s.methods, reg.method)
+		}
+	}
+}
+
+// matches reports if a path is in the scope.  For scopes that do not
+// add to the path, the path must also be the path of one of the routes
+// in the scope, or differ from one by a trailing slash.
+func (s *scope) matches(path string, segments []string) bool {
+	if !s.matchesPrefix(segments) {
+		return false
+	}
+	if s.members == nil {
+		return true
+	}
+	path = httprouter.CleanPath(path)
+	for _, method := range s.methods {
+		handle, _, tsr := s.members.Lookup(method, path)
+		if handle != nil || tsr {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *scope) matchesPrefix(segments []string) bool {
+	for i, p := range s.prefix {
+		if strings.HasPrefix(p, "*") {
+			return true
+		}
+		if i >= len(segments) {
+			return false
+		}
+		if c := strings.IndexByte(p, ':'); c != -1 {
+			if len(segments[i]) <= c || segments[i][:c] != p[:c] {
+				return false
+			}
+			continue
+		}
+		if p != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *table) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	router, override := t.routerFor(r.URL.Path)
+	r = overrideMethod(override, r)
+	if t.fallback != nil && !t.methods[r.Method] && t.serveFallback(router, w, r) {
+		return
+	}
+	router.ServeHTTP(w, r)
+}
+
+// addFallback registers a handle for requests whose method is not
+// used by any route
+func (t *table) addFallback(path string, handle httprouter.Handle) error {
+	if t.fallback == nil {
+		t.fallback = httprouter.New()
+	}
+	return catchPanic(func() {
+		t.fallback.Handle(anyMethod, path, handle)
+	})
+}
+
+// serveFallback handles a request with the fallback routes.  It returns
+// false if there is no fallback route for the path.
+func (t *table) serveFallback(router *httprouter.Router, w http.ResponseWriter, r *http.Request) bool {
+	handle, params, _ := t.fallback.Lookup(anyMethod, r.URL.Path)
+	if handle == nil {
+		return false
+	}
+	if router.PanicHandler != nil {
+		defer func() {
+			if rcv := recover(); rcv != nil {
+				router.PanicHandler(w, r, rcv)
+			}
+		}()
+	}
+	handle(w, r, params)
+	return true
+}
+
+func (t *table) notFound(w http.ResponseWriter, r *http.Request) {
+	router, _ := t.routerFor(r.URL.Path)
+	if router.NotFound != nil {
+		router.NotFound.ServeHTTP(w, r)
+	} else {
+		http.NotFound(w, r)
+	}
+}
@@ -0,0 +1,125 @@
+package nchi
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// RouteName can be passed as one of the providers to Method, Get,
+// Post, etc.  It is not injected.  Instead it names the route so that
+// URL can generate paths for it.
+//
+//	mux.Get("/articles/:articleID", nchi.RouteName("article"), getArticle)
+type RouteName string
+
+// URL generates a concrete path for the route that was registered with
+// the RouteName name.  The params are pairs of path variable names and
+// values: every :param and *catchall in the route must be supplied and
+// no others may be supplied.
+//
+//	path, err := mux.URL("article", "articleID", "38")
+//
+// Values are escaped.  Only the value of a catchall may include slashes.
+//
+// For routes inside a Version, the path includes the version prefix
+// (see WithVersionPrefix).  If versions are not selected by path, an
+// error is returned.
+// Values must match the constraints, if any, of their path variable.
+func (mux *Mux) URL(name string, params ...string) (string, error) {
+	if len(params)%2 != 0 {
+		return "", errors.Errorf("URL %s: params must be name/value pairs", name)
+	}
+	var found []string
+	var route *leaf
+	_ = mux.walkAll(func(l *leaf) error {
+		if l.mux.name == RouteName(name) && l.mux.special == nil {
+			found = append(found, l.combinedPath)
+			route = l
+		}
+		return nil
+	})
+	switch len(found) {
+	case 0:
+		return "", errors.Errorf("URL %s: no route with that name", name)
+	case 1:
+	default:
+		return "", errors.Errorf("URL %s: name is used by more than one route: %s", name, strings.Join(found, ", "))
+	}
+	values := make(map[string]string)
+	for i := 0; i < len(params); i += 2 {
+		if _, ok := values[params[i]]; ok {
+			return "", errors.Errorf("URL %s: param %s given more than once", name, params[i])
+		}
+		values[params[i]] = params[i+1]
+	}
+	pattern, pcs, err := route.routerPattern(constraintsFor(route.options))
+	if err != nil {
+		return "", errors.Wrapf(err, "URL %s", name)
+	}
+	for _, pc := range pcs {
+		if v, ok := values[pc.name]; ok && !pc.match(v) {
+			return "", errors.Errorf("URL %s: value for :%s does not match {%s}", name, pc.name, pc.spec)
+		}
+	}
+	p, err := fillPattern(pattern, values)
+	if err != nil {
+		return "", errors.Wrapf(err, "URL %s", name)
+	}
+	if route.version != "" {
+		prefix := versioningFor(mux.options).prefix
+		if prefix == "" {
+			return "", errors.Errorf("URL %s: the route is in Version %s which is not selected by path", name, route.version)
+		}
+		p = prefix + route.version + p
+	}
+	return p, nil
+}
+
+// fillPattern substitutes values into an httprouter-style pattern
+func fillPattern(pattern string, values map[string]string) (string, error) {
+	var b strings.Builder
+	used := make(map[string]bool)
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		if c != ':' && c != '*' {
+			_ = b.WriteByte(c)
+			continue
+		}
+		end := strings.IndexByte(pattern[i:], '/')
+		if end == -1 {
+			end = len(pattern)
+		} else {
+			end += i
+		}
+		param := pattern[i+1 : end]
+		i = end - 1
+		value, ok := values[param]
+		if !ok {
+			return "", errors.Errorf("missing value for %c%s", c, param)
+		}
+		used[param] = true
+		if c == ':' {
+			if value == "" {
+				return "", errors.Errorf("empty value for %c%s", c, param)
+			}
+			if strings.Contains(value, "/") {
+				return "", errors.Errorf("value for %c%s contains /", c, param)
+			}
+			_, _ = b.WriteString(url.PathEscape(value))
+			continue
+		}
+		segments := strings.Split(strings.TrimPrefix(value, "/"), "/")
+		for j, segment := range segments {
+			segments[j] = url.PathEscape(segment)
+		}
+		_, _ = b.WriteString(strings.Join(segments, "/"))
+	}
+	for param := range values {
+		if !used[param] {
+			return "", errors.Errorf("%s is not a parameter of %s", param, pattern)
+		}
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,58 @@
+package nchi_test
+
+import (
+	"testing"
+
+	"github.com/muir/nchi"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestURL(t *testing.T) {
+	admin := nchi.NewRouter()
+	admin.Use("")
+	admin.Get("/users/:userID", nchi.RouteName("user"), bottom)
+
+	mux := nchi.NewRouter()
+	mux.Use("")
+	mux.Route("/articles", func(r *nchi.Mux) {
+		r.Get("/:articleID", nchi.RouteName("article"), makeDown("a"), bottom)
+		r.Get("/:articleID/files/*path", nchi.RouteName("file"), bottom)
+	})
+	mux.Get("/dup1", nchi.RouteName("dup"), bottom)
+	mux.Get("/dup2", nchi.RouteName("dup"), bottom)
+	mux.Mount("/admin", admin)
+
+	cases := []struct {
+		name   string
+		params []string
+		want   string
+		err    string
+	}{
+		{name: "article", params: []string{"articleID", "38"}, want: "/articles/38"},
+		{name: "article", params: []string{"articleID", "a b?c"}, want: "/articles/a%20b%3Fc"},
+		{name: "article", params: []string{"articleID", "a/c"}, err: "URL article: value for :articleID contains /"},
+		{name: "file", params: []string{"articleID", "9", "path", "/x y/z"}, want: "/articles/9/files/x%20y/z"},
+		{name: "user", params: []string{"userID", "u"}, want: "/admin/users/u"},
+		{name: "article", err: "URL article: missing value for :articleID"},
+		{name: "article", params: []string{"articleID", ""}, err: "URL article: empty value for :articleID"},
+		{name: "article", params: []string{"articleID"}, err: "URL article: params must be name/value pairs"},
+		{name: "article", params: []string{"articleID", "1", "x", "2"}, err: "URL article: x is not a parameter of /articles/:articleID"},
+		{name: "nope", err: "URL nope: no route with that name"},
+		{name: "dup", err: "URL dup: name is used by more than one route: /dup1, /dup2"},
+	}
+	for _, tc := range cases {
+		got, err := mux.URL(tc.name, tc.params...)
+		if tc.err != "" {
+			assert.EqualError(t, err, tc.err, tc.name)
+			continue
+		}
+		if assert.NoError(t, err, tc.name) {
+			assert.Equal(t, tc.want, got, tc.name)
+		}
+	}
+
+	doTest(t, mux, []testCase{
+		{path: "/articles/38", want: "a"},
+	})
+}
@@ -0,0 +1,268 @@
+package nchi
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/muir/nject/v2"
+)
+
+// APIVersion is a type that handlers can accept as an input when
+// Version is used.  It is the version that was requested.  It is empty
+// when the request did not ask for a version.
+type APIVersion string
+
+type apiVersionKey struct{}
+
+// versioning is how the requested version is found
+type versioning struct {
+	prefix    string
+	header    string
+	mediaType string
+}
+
+// Version establishes a new Mux for the routes of one version of an API.
+// Like Route, the new Mux inherits middleware, but it does not add to
+// the path.
+//
+// Requests that ask for a version are handled by the routes for that
+// version.  For methods and paths that the version does not have, the
+// routes of the closest earlier version are used, and then the routes
+// that were not established inside any Version.  Requests that do not
+// ask for a version only use routes that are not inside a Version.
+//
+// Versions are ordered by comparing their dot-separated parts, numerically
+// when both parts are numbers: "2" is before "10" and "1.2" is before "1.10".
+//
+// How a request asks for a version is controlled with WithVersionPrefix,
+// WithVersionHeader, and WithVersionMediaType.  If none of those are given
+// to NewRouter, WithVersionPrefix("/v") is used.
+//
+// The requested version can be injected as APIVersion.
+//
+// Version cannot be used inside another Version: Bind reports an error
+// for each route of the inner Version.
+func (mux *Mux) Version(version string, f func(mux *Mux)) {
+	f(mux.add(&Mux{
+		providers: nject.Sequence(mux.path),
+		kind:      "Version",
+		version:   version,
+	}))
+}
+
+// WithVersionPrefix causes requests to ask for a version with a path
+// prefix: with a prefix of "/v", a request for "/v2/users" asks for
+// version "2" and is routed as if it were a request for "/users".  This
+// Option is only used when given to NewRouter.
+func WithVersionPrefix(prefix string) Option {
+	return func(r *rtr) {
+		r.versioning.prefix = prefix
+	}
+}
+
+// WithVersionHeader causes requests to ask for a version with a header.
+// With "X-Api-Version", a request with "X-Api-Version: 2" asks for
+// version "2".  Requests that ask for an unknown version get a 406
+// (Not Acceptable) response.  This Option is only used when given to
+// NewRouter.
+func WithVersionHeader(name string) Option {
+	return func(r *rtr) {
+		r.versioning.header = name
+	}
+}
+
+// WithVersionMediaType causes requests to ask for a version with
+// the Accept header.  With a vendor of "acme", a request that accepts
+// "application/vnd.acme.v2+json" asks for version "2".  Requests that
+// ask for an unknown version get a 406 (Not Acceptable) response.  This
+// Option is only used when given to NewRouter.
+func WithVersionMediaType(vendor string) Option {
+	return func(r *rtr) {
+		r.versioning.mediaType = "application/vnd." + strings.ToLower(vendor) + ".v"
+	}
+}
+
+// hasVersions reports if Version was used anywhere in the tree.
+// Mounted Muxes are only examined once.
+func (mux *Mux) hasVersions(seen map[*Mux]bool) bool {
+	if mux.version != "" {
+		return true
+	}
+	if mux.mounted != nil {
+		if seen[mux.mounted] {
+			return false
+		}
+		seen[mux.mounted] = true
+		return mux.mounted.hasVersions(seen)
+	}
+	for _, route := range mux.routes {
+		if route.hasVersions(seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// findVersions collects the versions used in the tree
+func (mux *Mux) findVersions(b *bound) {
+	seen := make(map[string]bool)
+	_ = mux.walkAll(func(l *leaf) error {
+		if l.version != "" && !seen[l.version] {
+			seen[l.version] = true
+			b.versions = append(b.versions, l.version)
+		}
+		return nil
+	})
+	if len(b.versions) == 0 {
+		return
+	}
+	b.versioning = versioningFor(mux.options)
+}
+
+// versioningFor returns how requests ask for versions
+func versioningFor(options []Option) versioning {
+	v := applyOptions(options).versioning
+	if v == (versioning{}) {
+		v.prefix = "/v"
+	}
+	return v
+}
+
+// selectVersion finds the version that a request asks for.  If the
+// request asks for a version that does not exist, a response is
+// written and false is returned.
+func (b *bound) selectVersion(w http.ResponseWriter, r *http.Request) (*http.Request, bool) {
+	var version string
+	if prefix := b.versioning.prefix; prefix != "" && strings.HasPrefix(r.URL.Path, prefix) {
+		rest := r.URL.Path[len(prefix):]
+		for _, v := range b.versions {
+			if rest == v || strings.HasPrefix(rest, v+"/") {
+				version = v
+				path := rest[len(v):]
+				if path == "" {
+					path = "/"
+				}
+				r = stripPrefix(r, path)
+				break
+			}
+		}
+	}
+	if version == "" && b.versioning.header != "" {
+		version = strings.TrimSpace(r.Header.Get(b.versioning.header))
+	}
+	if version == "" && b.versioning.mediaType != "" {
+		version = mediaTypeVersion(b.versioning.mediaType, r.Header.Values("Accept"))
+	}
+	if version == "" {
+		return r, true
+	}
+	if !b.knownVersion(version) {
+		http.Error(w, http.StatusText(http.StatusNotAcceptable), http.StatusNotAcceptable)
+		return nil, false
+	}
+	return r.WithContext(context.WithValue(r.Context(), apiVersionKey{}, APIVersion(version))), true
+}
+
+func (b *bound) knownVersion(version string) bool {
+	for _, v := range b.versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// mediaTypeVersion finds a version in Accept headers.  The prefix
+// is "application/vnd.vendor.v".
+func mediaTypeVersion(prefix string, accept []string) string {
+	for _, header := range accept {
+		for _, mediaRange := range strings.Split(header, ",") {
+			mediaType := strings.ToLower(strings.TrimSpace(strings.SplitN(mediaRange, ";", 2)[0]))
+			if !strings.HasPrefix(mediaType, prefix) {
+				continue
+			}
+			version := mediaType[len(prefix):]
+			if plus := strings.IndexByte(version, '+'); plus != -1 {
+				version = version[:plus]
+			}
+			if version != "" {
+				return version
+			}
+		}
+	}
+	return ""
+}
+
+// compareVersions returns -1, 0, or 1.  The empty version is
+// before all others.
+func compareVersions(a, b string) int {
+	if a == b {
+		return 0
+	}
+	if a == "" {
+		return -1
+	}
+	if b == "" {
+		return 1
+	}
+	ap := strings.Split(a, ".")
+	bp := strings.Split(b, ".")
+	for i := 0; i < len(ap) && i < len(bp); i++ {
+		if ap[i] == bp[i] {
+			continue
+		}
+		an, aErr := strconv.Atoi(ap[i])
+		bn, bErr := strconv.Atoi(bp[i])
+		switch {
+		case aErr == nil && bErr == nil && an < bn:
+			return -1
+		case aErr == nil && bErr == nil:
+			return 1
+		case ap[i] < bp[i]:
+			return -1
+		default:
+			return 1
+		}
+	}
+	switch {
+	case len(ap) < len(bp):
+		return -1
+	case len(ap) > len(bp):
+		return 1
+	}
+	return 0
+}
+
+// versionMatcher returns a Matcher for routes established inside Version
+func versionMatcher(version string) Matcher {
+	return Matcher{
+		name: "Version(" + version + ")",
+		match: func(r *http.Request) bool {
+			requested, _ := r.Context().Value(apiVersionKey{}).(APIVersion)
+			return compareVersions(string(requested), version) >= 0
+		},
+	}
+}
+
+// matchers returns the Matchers of the leaf including the
+// Matcher for its Version, if any.
+func (l *leaf) matchers() []Matcher {
+	if l.version == "" {
+		return l.mux.matchers
+	}
+	return append([]Matcher{versionMatcher(l.version)}, l.mux.matchers...)
+}
+
+// apiVersion returns a provider for APIVersion.  Like hostParams,
+// there is no such provider unless Version is used.
+func (l *leaf) apiVersion() *nject.Collection {
+	if !l.versioned {
+		return nject.Sequence("APIVersion")
+	}
+	return nject.Sequence("APIVersion", func(r *http.Request) APIVersion {
+		version, _ := r.Context().Value(apiVersionKey{}).(APIVersion)
+		return version
+	})
+}
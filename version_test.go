@@ -0,0 +1,129 @@
+package nchi_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/muir/nchi"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func versionedRouter(options ...nchi.Option) *nchi.Mux {
+	show := func(s string) func(w http.ResponseWriter, v nchi.APIVersion, e nchi.Endpoint) {
+		return func(w http.ResponseWriter, v nchi.APIVersion, e nchi.Endpoint) {
+			_, _ = w.Write([]byte(s + " " + string(v) + " " + string(e)))
+		}
+	}
+	mux := nchi.NewRouter(options...)
+	mux.Get("/users", show("base"))
+	mux.Get("/orders", show("base"))
+	mux.Version("10", func(mux *nchi.Mux) {
+		mux.Get("/users", show("v10"))
+	})
+	mux.Version("2", func(mux *nchi.Mux) {
+		mux.Get("/users", show("v2"))
+		mux.Get("/items/:id", show("v2"))
+	})
+	mux.Version("3", func(mux *nchi.Mux) {
+		mux.Get("/orders", show("v3"))
+	})
+	return mux
+}
+
+func TestVersionPrefix(t *testing.T) {
+	mux := versionedRouter()
+	doTest(t, mux, []testCase{
+		{path: "/users", want: "base  /users"},
+		{path: "/v2/users", want: "v2 2 /users"},
+		{path: "/v3/users", want: "v2 3 /users"},
+		{path: "/v10/users", want: "v10 10 /users"},
+		{path: "/v2/orders", want: "base 2 /orders"},
+		{path: "/v10/orders", want: "v3 10 /orders"},
+		{path: "/v3/items/7", want: "v2 3 /items/:id"},
+		{path: "/items/7", want: "404 page not found\n"},
+		{path: "/v9/users", want: "404 page not found\n"},
+	})
+}
+
+func TestVersionURL(t *testing.T) {
+	build := func(options ...nchi.Option) *nchi.Mux {
+		mux := nchi.NewRouter(options...)
+		mux.Get("/users", nchi.RouteName("base"), func() {})
+		mux.Version("2", func(mux *nchi.Mux) {
+			mux.Get("/users/:id", nchi.RouteName("user"), func() {})
+		})
+		return mux
+	}
+
+	mux := build()
+	url, err := mux.URL("user", "id", "7")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "/v2/users/7", url)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+		assert.Equal(t, 200, w.Code)
+	}
+	url, err = mux.URL("base")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "/users", url)
+	}
+
+	url, err = build(nchi.WithVersionPrefix("/api/v")).URL("user", "id", "7")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "/api/v2/users/7", url)
+	}
+
+	_, err = build(nchi.WithVersionHeader("X-Api-Version")).URL("user", "id", "7")
+	assert.EqualError(t, err, "URL user: the route is in Version 2 which is not selected by path")
+}
+
+func TestVersionHeaders(t *testing.T) {
+	mux := versionedRouter(nchi.WithVersionHeader("X-Api-Version"), nchi.WithVersionMediaType("Acme"))
+	cases := []struct {
+		name   string
+		header string
+		accept string
+		status int
+		want   string
+	}{
+		{name: "none", status: 200, want: "base  /users"},
+		{name: "header", header: "3", status: 200, want: "v2 3 /users"},
+		{name: "media type", accept: "text/html, application/vnd.acme.v10+json;q=0.9", status: 200, want: "v10 10 /users"},
+		{name: "unknown", header: "9", status: 406, want: "Not Acceptable\n"},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/users", nil)
+			if tc.header != "" {
+				r.Header.Set("X-Api-Version", tc.header)
+			}
+			if tc.accept != "" {
+				r.Header.Set("Accept", tc.accept)
+			}
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, r)
+			assert.Equal(t, tc.status, w.Code)
+			assert.Equal(t, tc.want, w.Body.String())
+		})
+	}
+}
+
+func TestVersionNested(t *testing.T) {
+	mux := nchi.NewRouter()
+	mux.Version("1", func(mux *nchi.Mux) {
+		mux.Version("2", func(mux *nchi.Mux) {
+			mux.Get("/x", func() {})
+		})
+	})
+	mux.Get("/y", func() {})
+	err := mux.Bind()
+	var bindError *nchi.BindError
+	if assert.ErrorAs(t, err, &bindError) {
+		assert.Equal(t, "/x", bindError.Path)
+		assert.EqualError(t, bindError.Cause, "Version 2 cannot be used inside Version 1")
+	}
+	assert.Len(t, mux.Routes(), 2)
+}
@@ -0,0 +1,92 @@
+package nchi
+
+import (
+	"github.com/muir/nject/v2"
+)
+
+// RouteInfo describes an endpoint or special handler.  It is
+// provided by Walk and Routes.
+type RouteInfo struct {
+	// Method is the HTTP method.  It is empty for special handlers
+	// that are not specific to a method and for MountHandler.  It
+	// is "*" for routes created with Any.
+	Method string
+	// Path is the combined path.  It is the same value that is
+	// injected as Endpoint.
+	Path string
+	// Host is the host pattern from Host.  It is empty for routes that
+	// are not inside a Host.
+	Host string
+	// Name is the RouteName given to the endpoint, if any.
+	Name string
+	// Matchers describes the Matchers given to the endpoint, if any.
+	Matchers []string
+	// Special is empty for regular endpoints.  For special handlers
+	// it is the name of the function that registered the handler:
+	// "ServeFiles", "GlobalOPTIONS", "MethodNotAllowed", "NotFound",
+	// "PanicHandler", or "MountHandler".
+	Special string
+	// Providers are the descriptions of each of the providers that will
+	// be part of the injection chain for the route.  Each description
+	// includes the name of the nject.Sequence that the provider is
+	// part of.  Not all providers will necessarily be included when the
+	// chain is bound.
+	Providers []string
+	// Via lists how the route was reached, outermost first.  The values
+	// are "Route", "Group", "With", "Without", "Mount", "Host", and
+	// "Version".  Via is empty for routes registered directly on the
+	// top-level Mux.
+	Via []string
+}
+
+// Walk visits every endpoint and special handler that will be registered
+// when the Mux is bound.  Walk stops and returns the error if the
+// visiting function returns an error.  Walk does not bind the Mux.
+func (mux *Mux) Walk(f func(RouteInfo) error) error {
+	return mux.walkAll(func(l *leaf) error {
+		return f(l.info())
+	})
+}
+
+// Routes returns the RouteInfo for every endpoint and special handler in
+// the order that Walk would visit them.
+func (mux *Mux) Routes() []RouteInfo {
+	var routes []RouteInfo
+	_ = mux.Walk(func(ri RouteInfo) error {
+		routes = append(routes, ri)
+		return nil
+	})
+	return routes
+}
+
+// describe returns the method and the special handler name, if any
+func (l *leaf) describe() (method string, special string) {
+	switch {
+	case l.mux.handler != nil:
+		return "", "MountHandler"
+	case l.mux.special != nil && l.mux.special.serveFiles != nil:
+		return "GET", l.mux.special.name()
+	case l.mux.special != nil:
+		return "", l.mux.special.name()
+	}
+	return l.mux.method, ""
+}
+
+func (l *leaf) info() RouteInfo {
+	method, special := l.describe()
+	ri := RouteInfo{
+		Method:  method,
+		Path:    l.combinedPath,
+		Host:    l.host,
+		Name:    string(l.mux.name),
+		Special: special,
+		Via:     append([]string(nil), l.via...),
+	}
+	for _, m := range l.matchers() {
+		ri.Matchers = append(ri.Matchers, m.String())
+	}
+	l.providers().ForEachProvider(func(p nject.Provider) {
+		ri.Providers = append(ri.Providers, p.String())
+	})
+	return ri
+}
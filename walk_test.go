@@ -0,0 +1,60 @@
+package nchi_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/muir/nchi"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalk(t *testing.T) {
+	admin := nchi.NewRouter()
+	admin.Get("/users", bottom)
+
+	mux := nchi.NewRouter()
+	mux.Use(makeDown("a"))
+	mux.Get("/a", bottom)
+	mux.Route("/r", func(r *nchi.Mux) {
+		r.With(makeDown("b")).Post("/:id", bottom)
+		r.Group(func(g *nchi.Mux) {
+			g.NotFound(bottom)
+		})
+	})
+	mux.Mount("/admin", admin)
+	mux.ServeFiles("/static/*filepath", http.Dir("."))
+
+	routes := mux.Routes()
+	type summary struct {
+		Method  string
+		Path    string
+		Special string
+		Via     []string
+	}
+	got := make([]summary, len(routes))
+	for i, ri := range routes {
+		got[i] = summary{Method: ri.Method, Path: ri.Path, Special: ri.Special, Via: ri.Via}
+	}
+	assert.Equal(t, []summary{
+		{Method: "GET", Path: "/a"},
+		{Method: "POST", Path: "/r/:id", Via: []string{"Route", "With"}},
+		{Path: "/r", Special: "NotFound", Via: []string{"Route", "Group"}},
+		{Method: "GET", Path: "/admin/users", Via: []string{"Mount"}},
+		{Method: "GET", Path: "/static/*filepath", Special: "ServeFiles"},
+	}, got)
+	require.Len(t, routes[1].Providers, 4)
+	assert.Contains(t, routes[1].Providers[1], "router")
+	assert.Contains(t, routes[1].Providers[3], "POST /:id")
+
+	stop := errors.New("stop")
+	var count int
+	err := mux.Walk(func(nchi.RouteInfo) error {
+		count++
+		return stop
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 1, count)
+}
@@ -0,0 +1,95 @@
+package nchi
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/muir/nject/v2"
+	"github.com/pkg/errors"
+)
+
+// Without is like With except that instead of adding providers, it
+// returns a new Mux that does not have some of the providers that would
+// otherwise be inherited.  This allows, for example, a health check to
+// use all of the middleware except for authentication:
+//
+//	auth := nject.Provide("auth", authenticate)
+//	mux.Use(logger, auth, metrics)
+//	mux.Without(auth).Get("/health", health)
+//	mux.Without("auth").Route("/public", publicRoutes)
+//
+// Each exclusion can be an nject.Provider or *nject.Collection, which
+// is matched by identity, or a string, which is matched against the name
+// of the providers (the name given to nject.Provide or, for providers
+// that were not named, the name of the nject.Sequence they are in).
+// Providers are only matched by identity if they were named with
+// nject.Provide before being passed to Use.
+//
+// If an exclusion does not match any inherited provider, Bind reports
+// an error for each route of the returned Mux.
+func (mux *Mux) Without(exclude ...interface{}) *Mux {
+	n := mux.add(&Mux{
+		providers: nject.Sequence(mux.path),
+		kind:      "Without",
+	})
+	n.providers, n.withoutErr = without(mux.providers, mux.path, exclude)
+	n.inherited = n.providers
+	return n
+}
+
+// without removes providers from a collection
+func without(c *nject.Collection, name string, exclude []interface{}) (*nject.Collection, error) {
+	matched := make([]bool, len(exclude))
+	var kept []interface{}
+	c.ForEachProvider(func(p nject.Provider) {
+		excluded := false
+		for i, e := range exclude {
+			if excludes(e, p) {
+				matched[i] = true
+				excluded = true
+			}
+		}
+		if !excluded {
+			kept = append(kept, p)
+		}
+	})
+	for i, e := range exclude {
+		switch e.(type) {
+		case string, nject.Provider:
+		default:
+			return c, errors.Errorf("cannot exclude %T: use a name or an nject.Provider", e)
+		}
+		if !matched[i] {
+			return c, errors.Errorf("%v is not inherited", e)
+		}
+	}
+	return nject.Sequence(name, kept...), nil
+}
+
+func excludes(e interface{}, p nject.Provider) bool {
+	switch e := e.(type) {
+	case string:
+		return providerName(p) == e
+	case *nject.Collection:
+		var found bool
+		e.ForEachProvider(func(q nject.Provider) {
+			found = found || q == p
+		})
+		return found
+	case nject.Provider:
+		return e == p
+	}
+	return false
+}
+
+var providerIndexRE = regexp.MustCompile(`\(\d+\)$`)
+
+// providerName extracts the name of a provider from its description,
+// which looks like "name(index) [type]" or "name [type]".
+func providerName(p nject.Provider) string {
+	s := p.String()
+	if i := strings.Index(s, " ["); i != -1 {
+		s = s[:i]
+	}
+	return providerIndexRE.ReplaceAllString(s, "")
+}
@@ -0,0 +1,53 @@
+package nchi_test
+
+import (
+	"testing"
+
+	"github.com/muir/nchi"
+	"github.com/muir/nject/v2"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithout(t *testing.T) {
+	auth := nject.Provide("auth", makeDown("auth-"))
+	bundle := nject.Sequence("bundle", makeDown("b1-"), makeDown("b2-"))
+	mux := nchi.NewRouter()
+	mux.Use("")
+	mux.Use(makeDown("log-"), auth, bundle)
+	mux.Use(nject.Provide("metrics", makeDown("metrics-")))
+	mux.Get("/all", bottom)
+	mux.Without(auth).Get("/health", bottom)
+	mux.Without("metrics", bundle).Route("/public", func(mux *nchi.Mux) {
+		mux.Use(makeDown("p-"))
+		mux.Get("/x", bottom)
+	})
+
+	doTest(t, mux, []testCase{
+		{path: "/all", want: "log-auth-b1-b2-metrics-"},
+		{path: "/health", want: "log-b1-b2-metrics-"},
+		{path: "/public/x", want: "log-auth-p-"},
+	})
+}
+
+func TestWithoutErrors(t *testing.T) {
+	for _, exclude := range []interface{}{
+		"missing",
+		nject.Provide("auth", makeDown("auth")),
+		makeDown("x"),
+	} {
+		mux := nchi.NewRouter()
+		mux.Use("", nject.Provide("auth", makeDown("auth")))
+		mux.Without(exclude).Get("/x", bottom)
+		mux.Get("/y", bottom)
+		mux.Get("/z", func(int) {})
+		err := mux.Bind()
+		var bindErrors nchi.BindErrors
+		if assert.ErrorAs(t, err, &bindErrors) && assert.Len(t, bindErrors, 2) {
+			assert.Equal(t, "/x", bindErrors[0].Path)
+			assert.Regexp(t, `^Without at .*without_test.go:\d+: `, bindErrors[0].Cause.Error())
+			assert.Equal(t, "/z", bindErrors[1].Path)
+		}
+		assert.Len(t, mux.Routes(), 3)
+	}
+}